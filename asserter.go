@@ -0,0 +1,61 @@
+package panics
+
+import "errors"
+
+// Asserter collects assertion failures instead of panicking on the first one, so several
+// fields can be validated and all failures reported at once. Each failure keeps the file and
+// line of the call that recorded it. The zero value is ready to use; an Asserter is not safe
+// for concurrent use.
+//
+// Example usage:
+//
+//	a := panics.New()
+//	a.OnBlank(cfg.Name, "name is required")
+//	a.OnNil(cfg.DB, "db is required")
+//	a.Panic()
+type Asserter struct {
+	failures []error
+}
+
+// New returns an empty Asserter.
+func New() *Asserter {
+	return &Asserter{}
+}
+
+// OnError records a failure if err is not nil.
+func (a *Asserter) OnError(err error, message string) {
+	a.record(checkError(1, err, message))
+}
+
+// OnNil records a failure if value is nil, including typed nils as in OnNil.
+func (a *Asserter) OnNil(value any, message string) {
+	a.record(checkNil(1, value, message))
+}
+
+// OnFalse records a failure if condition is false.
+func (a *Asserter) OnFalse(condition bool, message string) {
+	a.record(checkFalse(1, condition, message))
+}
+
+// OnBlank records a failure if the string value is blank (empty or whitespace).
+func (a *Asserter) OnBlank(value string, message string) {
+	a.record(checkBlank(1, value, message))
+}
+
+// Err returns all recorded failures joined with errors.Join, or nil if there are none.
+func (a *Asserter) Err() error {
+	return errors.Join(a.failures...)
+}
+
+// Panic panics with the joined failures if any were recorded.
+func (a *Asserter) Panic() {
+	if err := a.Err(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *Asserter) record(err error) {
+	if err != nil {
+		a.failures = append(a.failures, err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !panics_noassert
+
+package panics
+
+// assertionsCompiled reports whether the On assertions are compiled in. Building with
+// -tags panics_noassert sets it to false, see assertions_noassert.go. The assertions test it
+// directly rather than through enabled, so the compiler can see the constant and drop their
+// bodies.
+const assertionsCompiled = true
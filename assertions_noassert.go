@@ -0,0 +1,7 @@
+//go:build panics_noassert
+
+package panics
+
+// assertionsCompiled is false under the panics_noassert build tag, so the compiler drops the
+// bodies of the On assertions and calls to them cost nothing.
+const assertionsCompiled = false
@@ -0,0 +1,600 @@
+package panics
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// The Check functions mirror the On assertions, but return the error the assertion would have
+// panicked with instead of panicking. The error carries the same file, line and message, and
+// wraps the original error where there is one. They return nil when the assertion holds.
+
+// CheckError returns an error wrapping err if err is not nil, including an optional message
+// and the caller's file and line.
+func CheckError(err error, message string) error {
+	return checkError(1, err, message)
+}
+
+// CheckErrors returns an error if any of errs is not nil, listing the index and value of each
+// failed error.
+func CheckErrors(message string, errs ...error) error {
+	return checkErrors(1, message, errs)
+}
+
+// OnErrorReturn is the error-returning counterpart of OnError for named results: if err is not
+// nil, it stores in *errp the error OnError would have panicked with, wrapping err with the
+// message and the caller's file and line. *errp is left untouched when err is nil. Unlike the On
+// assertions, it is not turned off by SetEnabled.
+//
+// Example usage:
+//
+//	func (s *Store) Save(u User) (err error) {
+//	    _, execErr := s.db.Exec(insertUser, u.ID, u.Name)
+//	    OnErrorReturn(&err, execErr, "saving user")
+//	    return err
+//	}
+func OnErrorReturn(errp *error, err error, message string) {
+	if err := checkError(1, err, message); err != nil {
+		*errp = err
+	}
+}
+
+// CheckAnyError returns an error wrapping the non-nil errors of errs combined by errors.Join,
+// if there are any.
+func CheckAnyError(message string, errs []error) error {
+	return checkError(1, errors.Join(errs...), message)
+}
+
+// CheckUnexpectedError returns an error wrapping err if err is not nil and does not match any
+// of allowed with errors.Is.
+func CheckUnexpectedError(err error, message string, allowed ...error) error {
+	return checkUnexpectedError(1, err, message, allowed)
+}
+
+// CheckNil returns an error if value is nil, including typed nils as in OnNil.
+func CheckNil(value any, message string) error {
+	return checkNil(1, value, message)
+}
+
+// CheckNilChan returns an error if ch is a nil channel or not a channel at all.
+func CheckNilChan(ch any, message string) error {
+	return checkNilKind(1, ch, reflect.Chan, message, "nil channel")
+}
+
+// CheckNilFunc returns an error if fn is a nil func or not a func at all.
+func CheckNilFunc(fn any, message string) error {
+	return checkNilKind(1, fn, reflect.Func, message, "nil func")
+}
+
+// CheckFalse returns an error if condition is false.
+func CheckFalse(condition bool, message string) error {
+	return checkFalse(1, condition, message)
+}
+
+// CheckTrue returns an error if condition is true.
+func CheckTrue(condition bool, message string) error {
+	return checkTrue(1, condition, message)
+}
+
+// CheckBlank returns an error if the string value is blank (empty or whitespace).
+func CheckBlank(value string, message string) error {
+	return checkBlank(1, value, message)
+}
+
+// CheckInvalidUTF8 returns an error naming the byte offset of the first invalid sequence if
+// value is not valid UTF-8.
+func CheckInvalidUTF8(value string, message string) error {
+	return checkInvalidUTF8(1, value, message)
+}
+
+// CheckShorterThan returns an error if value has fewer than min runes.
+func CheckShorterThan(value string, min int, message string) error {
+	return checkShorterThan(1, value, min, message)
+}
+
+// CheckLongerThan returns an error if value has more than max runes.
+func CheckLongerThan(value string, max int, message string) error {
+	return checkLongerThan(1, value, max, message)
+}
+
+// CheckEmpty returns an error if the slice has no elements.
+func CheckEmpty[T any](collection []T, message string) error {
+	return checkLen(1, len(collection), message, "empty slice")
+}
+
+// CheckEmptyMap returns an error if the map has no entries.
+func CheckEmptyMap[K comparable, V any](collection map[K]V, message string) error {
+	return checkLen(1, len(collection), message, "empty map")
+}
+
+// CheckEmptyString returns an error if the string has zero length.
+func CheckEmptyString(value string, message string) error {
+	return checkLen(1, len(value), message, "empty string")
+}
+
+// CheckLenNot returns an error if the slice does not have exactly expected elements.
+func CheckLenNot[T any](s []T, expected int, message string) error {
+	return checkLenNot(1, len(s), expected, message)
+}
+
+// CheckLenNotMap returns an error if the map does not have exactly expected entries.
+func CheckLenNotMap[K comparable, V any](m map[K]V, expected int, message string) error {
+	return checkLenNot(1, len(m), expected, message)
+}
+
+// CheckMissingKey returns the value stored under key in m, or an error naming key if it is not
+// present.
+func CheckMissingKey[K comparable, V any](m map[K]V, key K, message string) (V, error) {
+	return checkMissingKey(1, m, key, message)
+}
+
+// CheckDuplicate returns an error naming the first duplicate if any element of s appears more
+// than once.
+func CheckDuplicate[T comparable](s []T, message string) error {
+	return checkDuplicate(1, s, message)
+}
+
+// CheckZero returns an error if value is the zero value for its type.
+func CheckZero[T comparable](value T, message string) error {
+	return checkZero(1, value, message)
+}
+
+// CheckEqual returns an error if a equals b.
+func CheckEqual[T comparable](a, b T, message string) error {
+	return checkEqual(1, a, b, message)
+}
+
+// CheckNotEqual returns an error if a differs from b.
+func CheckNotEqual[T comparable](a, b T, message string) error {
+	return checkNotEqual(1, a, b, message)
+}
+
+// CheckNegative returns an error if value is below zero.
+func CheckNegative[T SignedNumber](value T, message string) error {
+	return checkNegative(1, value, message)
+}
+
+// CheckOutOfRange returns an error if value is outside the inclusive range [min, max], or is NaN.
+func CheckOutOfRange[T cmp.Ordered](value, min, max T, message string) error {
+	return checkOutOfRange(1, value, min, max, message)
+}
+
+// CheckNaN returns an error if value is NaN.
+func CheckNaN[T Float](value T, message string) error {
+	return checkNaN(1, value, message)
+}
+
+// CheckInf returns an error if value is positive or negative infinity.
+func CheckInf[T Float](value T, message string) error {
+	return checkInf(1, value, message)
+}
+
+// CheckNegativeDuration returns an error if d is below zero.
+func CheckNegativeDuration(d time.Duration, message string) error {
+	return checkNegativeDuration(1, d, message)
+}
+
+// CheckZeroTime returns an error if t is the zero time.
+func CheckZeroTime(t time.Time, message string) error {
+	return checkZeroTime(1, t, message)
+}
+
+// CheckNotType returns value as a T, or an error naming the expected and actual type if value
+// is not a T.
+func CheckNotType[T any](value any, message string) (T, error) {
+	return checkNotType[T](1, value, message)
+}
+
+// CheckContextDone returns an error wrapping ctx.Err() if ctx is cancelled or past its
+// deadline, or an error if ctx is nil.
+func CheckContextDone(ctx context.Context, message string) error {
+	return checkContextDone(1, ctx, message)
+}
+
+// includeStack is set with SetIncludeStack.
+var includeStack atomic.Bool
+
+// SetIncludeStack controls whether the On and Check assertions append the full stack trace
+// of the caller to their message, in addition to the file and line. It is off by default to
+// keep messages short; frames of the panics package itself are left out.
+//
+// Example usage:
+//
+//	panics.SetIncludeStack(os.Getenv("ENV") == "dev")
+func SetIncludeStack(include bool) {
+	includeStack.Store(include)
+}
+
+// assertionsDisabled is set by SetEnabled(false).
+var assertionsDisabled atomic.Bool
+
+// SetEnabled turns the On assertions on or off. They are enabled by default. When disabled, they
+// return immediately without checking anything or capturing the caller, so assertions can be
+// left in hot paths of release builds; OnNotType still returns the value when it is a T. The
+// Check functions, Must and Must2 are not affected.
+//
+// Example usage:
+//
+//	func init() {
+//	    panics.SetEnabled(os.Getenv("APP_ASSERTIONS") != "off")
+//	}
+func SetEnabled(enabled bool) {
+	assertionsDisabled.Store(!enabled)
+}
+
+// assertionGuards counts the Guards that have not been restored yet.
+var assertionGuards atomic.Int64
+
+// AssertionGuard is returned by Guard; its Restore method ends the guard.
+type AssertionGuard struct {
+	restored atomic.Bool
+}
+
+// Guard turns the On assertions off until Restore is called on the returned guard, e.g. in
+// tests that trigger assertions on purpose. Guards nest and may be used from concurrent
+// goroutines: assertions are back on once every guard has been restored and SetEnabled has not
+// turned them off. As the setting is global, assertions are off for all goroutines meanwhile.
+//
+// Example usage:
+//
+//	defer panics.Guard().Restore()
+func Guard() *AssertionGuard {
+	assertionGuards.Add(1)
+	return &AssertionGuard{}
+}
+
+// Restore ends the guard. Calling it more than once has no further effect.
+func (g *AssertionGuard) Restore() {
+	if g.restored.CompareAndSwap(false, true) {
+		assertionGuards.Add(-1)
+	}
+}
+
+// WithAssertionsDisabled runs fn with the On assertions turned off as by Guard, restoring them
+// when fn returns or panics.
+//
+// Example usage:
+//
+//	panics.WithAssertionsDisabled(func() {
+//	    process(malformedInput)
+//	})
+func WithAssertionsDisabled(fn func()) {
+	defer Guard().Restore()
+	fn()
+}
+
+// enabled reports whether the On assertions have not been turned off with SetEnabled or Guard.
+func enabled() bool {
+	return !assertionsDisabled.Load() && assertionGuards.Load() == 0
+}
+
+// SignedNumber is the set of signed integer and floating-point types accepted by OnNegative.
+type SignedNumber interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+// Float is the set of floating-point types accepted by OnNaN and OnInf.
+type Float interface {
+	~float32 | ~float64
+}
+
+// The unexported check functions implement both the On and Check assertions. skip is the
+// number of frames above the check function's caller to attribute the failure to, so 0
+// reports the direct caller.
+
+func checkError(skip int, err error, message string) error {
+	if err != nil {
+		return newAssertionError(skip+1, message, err)
+	}
+	return nil
+}
+
+func checkUnexpectedError(skip int, err error, message string, allowed []error) error {
+	for _, target := range allowed {
+		if errors.Is(err, target) {
+			return nil
+		}
+	}
+	return checkError(skip+1, err, message)
+}
+
+func checkErrors(skip int, message string, errs []error) error {
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("error %d: %w", i, err))
+		}
+	}
+	if len(failed) > 0 {
+		return newAssertionError(skip+1, message, errors.Join(failed...))
+	}
+	return nil
+}
+
+func checkNil(skip int, value any, message string) error {
+	if isNil(value) {
+		return newAssertionError(skip+1, message, "nil value")
+	}
+	return nil
+}
+
+func checkNilKind(skip int, value any, kind reflect.Kind, message string, detail string) error {
+	if value == nil {
+		return newAssertionError(skip+1, message, detail)
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != kind {
+		return newAssertionError(skip+1, message, fmt.Sprintf("expected a %s, got %T", kind, value))
+	}
+	if v.IsNil() {
+		return newAssertionError(skip+1, message, detail)
+	}
+	return nil
+}
+
+func checkFalse(skip int, condition bool, message string) error {
+	if !condition {
+		return newAssertionError(skip+1, message, nil)
+	}
+	return nil
+}
+
+func checkTrue(skip int, condition bool, message string) error {
+	if condition {
+		return newAssertionError(skip+1, message, nil)
+	}
+	return nil
+}
+
+func checkBlank(skip int, value string, message string) error {
+	if strings.TrimSpace(value) == "" {
+		return newAssertionError(skip+1, message, "blank string")
+	}
+	return nil
+}
+
+func checkInvalidUTF8(skip int, value string, message string) error {
+	if utf8.ValidString(value) {
+		return nil
+	}
+	for i, r := range value {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(value[i:]); size == 1 {
+				return newAssertionError(skip+1, message, fmt.Sprintf("invalid UTF-8 at byte offset %d", i))
+			}
+		}
+	}
+	return nil
+}
+
+func checkShorterThan(skip int, value string, min int, message string) error {
+	if n := utf8.RuneCountInString(value); n < min {
+		return newAssertionError(skip+1, message, fmt.Sprintf("length %d is shorter than %d", n, min))
+	}
+	return nil
+}
+
+func checkLongerThan(skip int, value string, max int, message string) error {
+	if n := utf8.RuneCountInString(value); n > max {
+		return newAssertionError(skip+1, message, fmt.Sprintf("length %d is longer than %d", n, max))
+	}
+	return nil
+}
+
+func checkLen(skip int, length int, message string, detail string) error {
+	if length == 0 {
+		return newAssertionError(skip+1, message, detail)
+	}
+	return nil
+}
+
+func checkLenNot(skip int, length int, expected int, message string) error {
+	if length != expected {
+		return newAssertionError(skip+1, message, fmt.Sprintf("expected length %d, got %d", expected, length))
+	}
+	return nil
+}
+
+func checkMissingKey[K comparable, V any](skip int, m map[K]V, key K, message string) (V, error) {
+	v, ok := m[key]
+	if !ok {
+		return v, newAssertionError(skip+1, message, fmt.Sprintf("missing key %v", key))
+	}
+	return v, nil
+}
+
+func checkDuplicate[T comparable](skip int, s []T, message string) error {
+	seen := make(map[T]int, len(s))
+	for i, v := range s {
+		if first, ok := seen[v]; ok {
+			return newAssertionError(skip+1, message, fmt.Sprintf("duplicate element %v at indexes %d and %d", v, first, i))
+		}
+		seen[v] = i
+	}
+	return nil
+}
+
+func checkZero[T comparable](skip int, value T, message string) error {
+	var zero T
+	if value == zero {
+		return newAssertionError(skip+1, message, "zero value")
+	}
+	return nil
+}
+
+func checkEqual[T comparable](skip int, a, b T, message string) error {
+	if a == b {
+		return newAssertionError(skip+1, message, fmt.Sprintf("values are equal: %v == %v", a, b))
+	}
+	return nil
+}
+
+func checkNotEqual[T comparable](skip int, a, b T, message string) error {
+	if a != b {
+		return newAssertionError(skip+1, message, fmt.Sprintf("values differ: %v != %v", a, b))
+	}
+	return nil
+}
+
+func checkNegative[T SignedNumber](skip int, value T, message string) error {
+	if value < 0 {
+		return newAssertionError(skip+1, message, fmt.Sprintf("value %v is negative", value))
+	}
+	return nil
+}
+
+func checkOutOfRange[T cmp.Ordered](skip int, value, min, max T, message string) error {
+	if cmp.Less(value, min) || cmp.Less(max, value) {
+		return newAssertionError(skip+1, message, fmt.Sprintf("value %v is outside [%v, %v]", value, min, max))
+	}
+	return nil
+}
+
+func checkNaN[T Float](skip int, value T, message string) error {
+	if math.IsNaN(float64(value)) {
+		return newAssertionError(skip+1, message, "value is NaN")
+	}
+	return nil
+}
+
+func checkInf[T Float](skip int, value T, message string) error {
+	if math.IsInf(float64(value), 0) {
+		return newAssertionError(skip+1, message, fmt.Sprintf("value %v is infinite", value))
+	}
+	return nil
+}
+
+func checkNegativeDuration(skip int, d time.Duration, message string) error {
+	if d < 0 {
+		return newAssertionError(skip+1, message, fmt.Sprintf("duration %s is negative", d))
+	}
+	return nil
+}
+
+func checkZeroTime(skip int, t time.Time, message string) error {
+	if t.IsZero() {
+		return newAssertionError(skip+1, message, "zero time")
+	}
+	return nil
+}
+
+func checkNotType[T any](skip int, value any, message string) (T, error) {
+	v, ok := value.(T)
+	if !ok {
+		return v, newAssertionError(skip+1, message, fmt.Sprintf("expected %s, got %T", reflect.TypeFor[T](), value))
+	}
+	return v, nil
+}
+
+func checkContextDone(skip int, ctx context.Context, message string) error {
+	if ctx == nil {
+		return newAssertionError(skip+1, message, "nil context")
+	}
+	if err := ctx.Err(); err != nil {
+		return newAssertionError(skip+1, message, err)
+	}
+	return nil
+}
+
+// newAssertionError builds the error for a failed assertion, reporting the file and line skip
+// frames above its caller. A detail that is an error is wrapped, so errors.Is and errors.As
+// keep working. When SetIncludeStack is enabled, the stack trace of the caller is appended.
+func newAssertionError(skip int, message string, detail any) error {
+	_, file, line, _ := runtime.Caller(skip + 1)
+	err := &assertionError{file: file, line: line, message: message, detail: detail}
+	if includeStack.Load() {
+		err.stack = formatFrames(callers(skip+1, 0))
+	}
+	return err
+}
+
+// assertionError is the error built by a failed assertion.
+type assertionError struct {
+	file    string
+	line    int
+	message string
+	detail  any
+	stack   []byte
+}
+
+// Error renders the assertion with the format set by SetMessageFormat, followed by the stack
+// trace if one was captured. The message and detail are cut to the SetMaxMessageLen limit.
+func (e *assertionError) Error() string {
+	msg := (*messageFormat.Load())(e.file, e.line, truncate(e.message), truncateDetail(e.detail))
+	if e.stack != nil {
+		msg += "Stacktrace:\n" + string(e.stack)
+	}
+	return msg
+}
+
+// Unwrap returns the detail when it is an error.
+func (e *assertionError) Unwrap() error {
+	err, _ := e.detail.(error)
+	return err
+}
+
+// messageFormat holds the formatter set with SetMessageFormat.
+var messageFormat atomic.Pointer[func(file string, line int, message string, detail any) string]
+
+func init() {
+	SetMessageFormat(nil)
+}
+
+// SetMessageFormat sets the function used to render failed assertions from the On and Check
+// functions, e.g. to emit JSON for a structured log parser. detail describes the failure; it
+// is the original error for OnError and similar, a description such as "nil value" otherwise,
+// and may be empty. Passing nil restores the default format. The stack trace enabled by
+// SetIncludeStack is appended after the formatted message.
+//
+// Example usage:
+//
+//	panics.SetMessageFormat(func(file string, line int, message string, detail any) string {
+//	    b, _ := json.Marshal(map[string]any{"file": file, "line": line, "message": message, "detail": fmt.Sprint(detail)})
+//	    return string(b)
+//	})
+func SetMessageFormat(fn func(file string, line int, message string, detail any) string) {
+	if fn == nil {
+		fn = defaultMessageFormat
+	}
+	messageFormat.Store(&fn)
+}
+
+// defaultMessageFormat renders an assertion as one "Key: value" line each for the file, line,
+// message and detail. The message and detail lines are left out when empty, so every assertion
+// renders the same way without dangling labels.
+func defaultMessageFormat(file string, line int, message string, detail any) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nFile: %s\nLine: %d\n", file, line)
+	if message != "" {
+		fmt.Fprintf(&b, "Message: %s\n", message)
+	}
+	if detail != nil {
+		if d := fmt.Sprint(detail); d != "" {
+			fmt.Fprintf(&b, "Error: %s\n", d)
+		}
+	}
+	return b.String()
+}
+
+// isNil reports whether value is nil or holds a nil pointer, map, slice, channel, func or
+// interface.
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface, reflect.UnsafePointer:
+		return v.IsNil()
+	}
+	return false
+}
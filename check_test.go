@@ -0,0 +1,116 @@
+package panics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsNil(t *testing.T) {
+	var (
+		ptr *int
+		m   map[string]int
+		s   []int
+		ch  chan int
+		fn  func()
+		err error
+		n   = 1
+	)
+	tests := []struct {
+		name  string
+		value any
+		want  bool
+	}{
+		{"untyped nil", nil, true},
+		{"typed nil pointer", ptr, true},
+		{"typed nil map", m, true},
+		{"typed nil slice", s, true},
+		{"typed nil chan", ch, true},
+		{"typed nil func", fn, true},
+		{"nil interface in interface", any(err), true},
+		{"pointer", &n, false},
+		{"pointer to nil interface", &err, false},
+		{"map", map[string]int{}, false},
+		{"slice", []int{}, false},
+		{"chan", make(chan int), false},
+		{"func", func() {}, false},
+		{"int", 0, false},
+		{"string", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNil(tt.value); got != tt.want {
+				t.Errorf("isNil(%#v) = %t, want %t", tt.value, got, tt.want)
+			}
+			if err := CheckNil(tt.value, "value"); (err != nil) != tt.want {
+				t.Errorf("CheckNil(%#v) = %v, want error %t", tt.value, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNilKind(t *testing.T) {
+	var (
+		ch     chan int
+		recv   <-chan int
+		fn     func(int) error
+		notNil = make(chan int)
+	)
+	tests := []struct {
+		name   string
+		check  func(any, string) error
+		value  any
+		detail any
+	}{
+		{"nil chan", CheckNilChan, ch, "nil channel"},
+		{"untyped nil chan", CheckNilChan, nil, "nil channel"},
+		{"nil receive-only chan", CheckNilChan, recv, "nil channel"},
+		{"receive-only chan", CheckNilChan, (<-chan int)(notNil), nil},
+		{"chan", CheckNilChan, notNil, nil},
+		{"chan wrong kind", CheckNilChan, 42, "expected a chan, got int"},
+		{"nil func", CheckNilFunc, fn, "nil func"},
+		{"untyped nil func", CheckNilFunc, nil, "nil func"},
+		{"func", CheckNilFunc, func() {}, nil},
+		{"func wrong kind", CheckNilFunc, notNil, "expected a func, got chan int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check(tt.value, "value")
+			if tt.detail == nil {
+				if err != nil {
+					t.Fatalf("got %v, want nil", err)
+				}
+				return
+			}
+			ae, ok := err.(*assertionError)
+			if !ok {
+				t.Fatalf("got %v, want an assertion error", err)
+			}
+			if ae.detail != tt.detail {
+				t.Errorf("detail = %v, want %v", ae.detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestCheckOutOfRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		fail  bool
+	}{
+		{"min", 0, false},
+		{"inside", 0.5, false},
+		{"max", 1, false},
+		{"below", -0.1, true},
+		{"above", 1.1, true},
+		{"NaN", math.NaN(), true},
+		{"+Inf", math.Inf(1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckOutOfRange(tt.value, 0, 1, "ratio"); (err != nil) != tt.fail {
+				t.Errorf("CheckOutOfRange(%v, 0, 1) = %v, want error %t", tt.value, err, tt.fail)
+			}
+		})
+	}
+}
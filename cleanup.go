@@ -0,0 +1,50 @@
+package panics
+
+import (
+	"errors"
+	"io"
+)
+
+// SafeClose calls Close on every closer, in order, even if earlier ones fail or panic. A panic
+// in Close is recovered as a *PanicError. It returns the errors joined with errors.Join, or nil
+// if all closers succeeded. Nil closers are skipped.
+//
+// Example usage:
+//
+//	defer panics.SafeClose(rows, tx, conn)
+func SafeClose(closers ...io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		if c == nil {
+			continue
+		}
+		if err := TryErr(c.Close); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Finalize recovers from a panic, runs cleanup and, if rethrow is true, panics again with the
+// original value. The recovered panic is logged. cleanup runs whether or not there was a
+// panic, like a deferred call would; a panic in cleanup is recovered and logged so it doesn't
+// mask the original one. It must be deferred directly.
+//
+// Example usage:
+//
+//	tx := Must(db.Begin())
+//	defer panics.Finalize(func() { tx.Rollback() }, true) // Rollback after Commit is a no-op
+func Finalize(cleanup func(), rethrow bool) {
+	r := recover()
+	if r != nil {
+		logPanic("Recovered from panic, running cleanup", recovered(r))
+	}
+	if cleanup != nil {
+		if err := Try(cleanup); err != nil {
+			logPanic("Recovered from panic in cleanup", err.(*PanicError))
+		}
+	}
+	if r != nil && rethrow {
+		panic(r)
+	}
+}
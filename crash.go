@@ -0,0 +1,21 @@
+package panics
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// InstallCrashHandler prepares a crash report for failures recover can't catch, such as a fatal
+// signal in cgo code, a concurrent map write or an unrecovered panic: the traceback is set to
+// include all goroutines, and the runtime is asked to also write it to f as the process dies.
+// It takes a file, not an io.Writer, since nothing else is guaranteed to run at that point.
+// Opening f in append mode keeps earlier reports.
+//
+// Example usage:
+//
+//	f := panics.Must(os.OpenFile("crash.log", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644))
+//	panics.OnError(panics.InstallCrashHandler(f), "installing crash handler")
+func InstallCrashHandler(f *os.File) error {
+	debug.SetTraceback("all")
+	return debug.SetCrashOutput(f, debug.CrashOptions{})
+}
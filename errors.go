@@ -0,0 +1,114 @@
+package panics
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// ErrTimeout is returned, wrapped, by TryWithTimeout when the function does not finish in time.
+var ErrTimeout = errors.New("timed out")
+
+// ErrPanic matches every *PanicError produced by a panic with errors.Is, so callers can tell
+// errors produced by a recovered panic from ordinary returned errors. Errors returned by
+// NewTraceError don't match it.
+//
+// Example usage:
+//
+//	if errors.Is(err, panics.ErrPanic) {
+//	    panicCounter.Inc()
+//	}
+var ErrPanic = errors.New("panic")
+
+// ErrRetriesExhausted is returned, wrapped together with the last error, by Retry and its
+// variants when they give up with fn still failing because the attempts ran out or the
+// deadline was reached. It is not wrapped when a ShouldRetry function rejects the error.
+var ErrRetriesExhausted = errors.New("retry failed")
+
+// PanicError is the error produced when a panic is recovered by RecoverAndHandle, Try,
+// TryResult and Retry, and the value WithTrace panics with. It keeps the original panic value
+// and the stack trace captured while the panicking goroutine was still unwinding.
+//
+// Example usage:
+//
+//	var pe *PanicError
+//	if errors.As(err, &pe) {
+//	    fmt.Printf("panic value: %v\n%s", pe.Value, pe.Stack)
+//	}
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine where the panic was raised, or where it was
+	// recovered if the value was not a *PanicError.
+	Stack []byte
+	// Frames is the stack as structured frames, starting at the code that panicked, for error
+	// tracking services that want file, line and function separately.
+	Frames []runtime.Frame
+	// Time is when the PanicError was created, i.e. when the panic was recovered or, for
+	// WithTrace and friends, raised.
+	Time time.Time
+	// GoroutineID is the ID of the goroutine the stack was captured on, parsed from the stack
+	// trace header. It is zero if the header could not be parsed.
+	GoroutineID uint64
+
+	// hooked records that the OnPanic hooks have run for this panic.
+	hooked bool
+	// returned records that the PanicError came from NewTraceError and was returned instead of
+	// panicked with, so it doesn't match ErrPanic. Recovering it as a panic clears it.
+	returned bool
+}
+
+// newPanicError builds a PanicError for a panic value. When recovering, it must be called
+// while the panicking goroutine is still unwinding, so that the stack contains the panicking
+// frames.
+func newPanicError(r any) *PanicError {
+	stack := debug.Stack()
+	return &PanicError{
+		Value:       r,
+		Stack:       stack,
+		Frames:      callers(1, 0),
+		Time:        time.Now(),
+		GoroutineID: goroutineID(stack),
+	}
+}
+
+// Error returns a readable message describing the panic value, without the stack trace. The
+// message of an error from NewTraceError that was never panicked with has no "panic: " prefix.
+func (e *PanicError) Error() string {
+	if e.returned {
+		return e.valueString()
+	}
+	return "panic: " + e.valueString()
+}
+
+// valueString formats the panic value. Errors and strings are shown as they are; other values,
+// such as panic(42) or panic(struct{}{}), are followed by their type, since the bare value is
+// often ambiguous. A nil value is shown as "nil". Since Go 1.21, panic(nil) arrives as a
+// *runtime.PanicNilError and is shown as that error.
+func (e *PanicError) valueString() string {
+	switch v := e.Value.(type) {
+	case nil:
+		return "nil"
+	case error:
+		return v.Error()
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v (%T)", v, v)
+	}
+}
+
+// Unwrap returns the panic value when it is an error, so errors.Is and errors.As can
+// inspect it.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Is reports whether target is ErrPanic, so errors.Is(err, ErrPanic) holds for every
+// *PanicError regardless of its value, except one returned by NewTraceError.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanic && !e.returned
+}
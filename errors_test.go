@@ -0,0 +1,33 @@
+package panics_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+func TestErrPanic(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		isPanic bool
+		msg     string
+	}{
+		{"recovered panic", panics.Try(func() { panic("boom") }), true, "panic: boom"},
+		{"WithTrace", panics.Try(func() { panics.WithTrace("boom") }), true, "panic: boom"},
+		{"NewTraceError", panics.NewTraceError("boom"), false, "boom"},
+		{"panicked NewTraceError", panics.Try(func() { panic(panics.NewTraceError("boom")) }), true, "panic: boom"},
+		{"ordinary error", errors.New("boom"), false, "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, panics.ErrPanic); got != tt.isPanic {
+				t.Errorf("errors.Is(err, ErrPanic) = %t, want %t", got, tt.isPanic)
+			}
+			if got := tt.err.Error(); got != tt.msg {
+				t.Errorf("Error() = %q, want %q", got, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package panics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// fatalExitCode holds the exit code set with SetFatalExitCode.
+var fatalExitCode atomic.Int32
+
+func init() {
+	fatalExitCode.Store(1)
+}
+
+// SetFatalExitCode sets the exit code used by Fatal and Fatalf. It defaults to 1.
+func SetFatalExitCode(code int) {
+	fatalExitCode.Store(int32(code))
+}
+
+// Fatal logs message with the stack trace of the calling goroutine through the package logger,
+// then exits the process with the code set with SetFatalExitCode. It is a replacement for
+// log.Fatal for conditions that must not be recovered, unlike WithTrace; deferred functions
+// are not run.
+//
+// Example usage:
+//
+//	if err := srv.ListenAndServe(); err != nil {
+//	    panics.Fatal("server stopped: " + err.Error())
+//	}
+func Fatal(message string) {
+	fatal(message)
+}
+
+// Fatalf is like Fatal, but builds the message from format and args.
+func Fatalf(format string, args ...any) {
+	fatal(fmt.Sprintf(format, args...))
+}
+
+// fatal logs message with the stack trace and exits.
+func fatal(message string) {
+	logger().Log(context.Background(), slog.LevelError, message, "stack", string(debug.Stack()))
+	os.Exit(int(fatalExitCode.Load()))
+}
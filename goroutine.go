@@ -0,0 +1,62 @@
+package panics
+
+import "sync"
+
+// Go runs fn in a new goroutine with a deferred Recover, so a panic in fn is logged instead
+// of crashing the process.
+//
+// Note that this changes crash semantics: an unrecovered panic in a goroutine normally
+// terminates the program, whereas with Go the goroutine simply ends and the program keeps
+// running. Only use it for work where that is the desired behaviour.
+//
+// Example usage:
+//
+//	panics.Go(func() {
+//	    processMessage(msg)
+//	})
+func Go(fn func()) {
+	go func() {
+		defer Recover()
+		fn()
+	}()
+}
+
+// GoHandle runs fn in a new goroutine and passes any panic, as a *PanicError, to onPanic
+// instead of crashing the process. The same change in crash semantics as Go applies.
+//
+// Example usage:
+//
+//	panics.GoHandle(func() {
+//	    processMessage(msg)
+//	}, func(err error) {
+//	    metrics.Inc("worker_panics")
+//	})
+func GoHandle(fn func(), onPanic func(err error)) {
+	go func() {
+		defer RecoverAndHandle(onPanic)
+		fn()
+	}()
+}
+
+// GoWait adds one to wg and runs fn in a new goroutine that calls wg.Done when it finishes.
+// A panic in fn is recovered and logged like Recover, and wg.Done is still called, so the
+// WaitGroup counter never leaks. It replaces the wg.Add(1); go func() { defer wg.Done() ... }()
+// pattern, with the same change in crash semantics as Go.
+//
+// Example usage:
+//
+//	var wg sync.WaitGroup
+//	for _, job := range jobs {
+//	    panics.GoWait(&wg, func() {
+//	        process(job)
+//	    })
+//	}
+//	wg.Wait()
+func GoWait(wg *sync.WaitGroup, fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer Recover()
+		fn()
+	}()
+}
@@ -0,0 +1,76 @@
+package panics
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Group runs functions in goroutines like golang.org/x/sync/errgroup, but recovers panics in
+// them: a panic is converted into a *PanicError and treated as that function's error, so a
+// worker panic never crashes the process. Wait returns the first error, whether returned or
+// recovered; later panics are logged. The zero value is ready to use and does not cancel
+// anything on failure.
+//
+// Example usage:
+//
+//	var g panics.Group
+//	for _, url := range urls {
+//	    g.Go(func() error {
+//	        return fetch(url)
+//	    })
+//	}
+//	err := g.Wait()
+type Group struct {
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelCauseFunc
+}
+
+// NewGroup returns a Group and a context derived from ctx. The context is cancelled the first
+// time a function returns an error or panics, or when Wait returns, whichever happens first.
+//
+// Example usage:
+//
+//	g, ctx := panics.NewGroup(ctx)
+//	g.Go(func() error { return produce(ctx) })
+//	g.Go(func() error { return consume(ctx) })
+//	err := g.Wait()
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go runs fn in a new goroutine, recovering any panic as a *PanicError.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if err := TryErr(fn); err != nil {
+			first := false
+			g.once.Do(func() {
+				first = true
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+			var pe *PanicError
+			if !first && errors.As(err, &pe) {
+				logPanic("recovered from panic in group", pe)
+			}
+		}
+	}()
+}
+
+// Wait blocks until all functions started with Go have finished and returns the first error,
+// if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
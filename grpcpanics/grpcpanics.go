@@ -0,0 +1,107 @@
+// Package grpcpanics provides gRPC server interceptors that recover panics in unary and
+// streaming handlers.
+//
+// It lives in its own module so that the base panics package stays free of the gRPC
+// dependency.
+package grpcpanics
+
+import (
+	"context"
+
+	"github.com/rizvn/panics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is returned to gRPC in place of a handler panic. It reports codes.Internal to the
+// client while still unwrapping to the *panics.PanicError for server-side inspection.
+type Error struct {
+	*panics.PanicError
+}
+
+// GRPCStatus returns the status sent to the client, without the panic details.
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(codes.Internal, "internal server error")
+}
+
+// Unwrap returns the underlying *panics.PanicError.
+func (e *Error) Unwrap() error {
+	return e.PanicError
+}
+
+// Option configures the interceptors returned by UnaryServerInterceptor and
+// StreamServerInterceptor.
+type Option func(*options)
+
+// options holds the interceptor configuration set by Option values.
+type options struct {
+	hook func(ctx context.Context, err error)
+}
+
+// newOptions applies opts to the default configuration.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithHook sets a function called with the call's context and the *panics.PanicError every
+// time a panic is recovered, after it has been logged. The context is the one passed to the
+// unary handler or the stream's Context, so hooks such as otelpanics.RecordPanic can reach the
+// call's span.
+func WithHook(fn func(ctx context.Context, err error)) Option {
+	return func(o *options) {
+		o.hook = fn
+	}
+}
+
+// UnaryServerInterceptor returns a unary interceptor that recovers panics in handlers, logs
+// the error and stack trace with the call's context, and returns a codes.Internal status to
+// the client.
+//
+// Example usage:
+//
+//	srv := grpc.NewServer(grpc.UnaryInterceptor(grpcpanics.UnaryServerInterceptor(
+//	    grpcpanics.WithHook(otelpanics.RecordPanic),
+//	)))
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer panics.RecoverAndHandle(func(recovered error) {
+			resp, err = nil, o.recoveredError(ctx, recovered, info.FullMethod)
+		})
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that recovers panics in handlers, logs
+// the error and stack trace with the stream's context, and ends the stream with a codes.Internal status. Panics raised
+// after some messages have already been sent are handled the same way: the stream is closed
+// with the error status.
+//
+// Example usage:
+//
+//	srv := grpc.NewServer(grpc.StreamInterceptor(grpcpanics.StreamServerInterceptor()))
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer panics.RecoverAndHandle(func(recovered error) {
+			err = o.recoveredError(ss.Context(), recovered, info.FullMethod)
+		})
+		return handler(srv, ss)
+	}
+}
+
+// recoveredError logs a panic recovered from method with ctx, calls the hook and converts the
+// panic into an *Error.
+func (o *options) recoveredError(ctx context.Context, recovered error, method string) error {
+	pe := recovered.(*panics.PanicError)
+	panics.LogPanicContext(ctx, "recovered from panic", pe, "method", method)
+	if o.hook != nil {
+		o.hook(ctx, pe)
+	}
+	return &Error{PanicError: pe}
+}
@@ -0,0 +1,102 @@
+package grpcpanics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rizvn/panics"
+	"github.com/rizvn/panics/grpcpanics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStream is a grpc.ServerStream that records the messages sent on it.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []any
+}
+
+func (s *fakeStream) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func (s *fakeStream) SendMsg(m any) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func checkPanicError(t *testing.T, err error) {
+	t.Helper()
+	if code := status.Code(err); code != codes.Internal {
+		t.Errorf("status.Code(err) = %v, want %v", code, codes.Internal)
+	}
+	var pe *panics.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want a *panics.PanicError", err)
+	}
+	if pe.Value != "boom" {
+		t.Errorf("pe.Value = %v, want boom", pe.Value)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	panics.SetLogger(nil)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"}
+	resp, err := grpcpanics.UnaryServerInterceptor()(context.Background(), "req", info, func(context.Context, any) (any, error) {
+		panic("boom")
+	})
+	if resp != nil {
+		t.Errorf("resp = %v, want nil", resp)
+	}
+	checkPanicError(t, err)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	panics.SetLogger(nil)
+
+	ss := &fakeStream{}
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream", IsServerStream: true}
+	err := grpcpanics.StreamServerInterceptor()(nil, ss, info, func(_ any, stream grpc.ServerStream) error {
+		if err := stream.SendMsg("first"); err != nil {
+			return err
+		}
+		panic("boom")
+	})
+	if len(ss.sent) != 1 {
+		t.Errorf("sent %d messages before the panic, want 1", len(ss.sent))
+	}
+	checkPanicError(t, err)
+}
+
+type ctxKey struct{}
+
+func TestWithHook(t *testing.T) {
+	panics.SetLogger(nil)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "call")
+	var got []string
+	hook := grpcpanics.WithHook(func(ctx context.Context, err error) {
+		var pe *panics.PanicError
+		if !errors.As(err, &pe) {
+			t.Errorf("hook err = %v, want a *panics.PanicError", err)
+		}
+		got = append(got, ctx.Value(ctxKey{}).(string))
+	})
+
+	grpcpanics.UnaryServerInterceptor(hook)(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+		panic("boom")
+	})
+	grpcpanics.StreamServerInterceptor(hook)(nil, &fakeStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(any, grpc.ServerStream) error {
+		panic("boom")
+	})
+	if len(got) != 2 || got[0] != "call" || got[1] != "call" {
+		t.Errorf("hook contexts = %v, want the call context twice", got)
+	}
+}
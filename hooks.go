@@ -0,0 +1,107 @@
+package panics
+
+import "sync"
+
+// panicHooks holds the hooks registered with OnPanic and notifyChans the channels returned by
+// NotifyChan, both guarded by hooksMu. The slices are never modified in place, so a copy of
+// the header can be iterated without holding the lock.
+var (
+	hooksMu     sync.RWMutex
+	panicHooks  []func(value any, stack []byte)
+	notifyChans []chan PanicError
+)
+
+// notifyBuffer is the buffer size of the channels returned by NotifyChan.
+const notifyBuffer = 64
+
+// OnPanic registers a hook that is called with the panic value and stack trace every time the
+// package recovers a panic, in Recover, RecoverAndHandle, Try, Retry, RecoveryMiddleware and
+// the other recovery helpers. It is intended for forwarding panics to a reporting service from
+// one place. Hooks run in registration order on the recovering goroutine; a panic inside a hook
+// is recovered and logged. OnPanic is safe for concurrent use.
+//
+// A value re-panicked by RecoverAndRepanic, Finalize or a Recoverer is reported again by each
+// outer recovery. A *PanicError is only reported the first time, so re-panicking with the error
+// returned by Try reports the panic once.
+//
+// Example usage:
+//
+//	panics.OnPanic(func(value any, stack []byte) {
+//	    sentry.CaptureMessage(fmt.Sprintf("panic: %v\n%s", value, stack))
+//	})
+func OnPanic(hook func(value any, stack []byte)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks := make([]func(value any, stack []byte), len(panicHooks), len(panicHooks)+1)
+	copy(hooks, panicHooks)
+	panicHooks = append(hooks, hook)
+}
+
+// NotifyChan returns a channel on which every panic recovered by the package is delivered,
+// e.g. for a supervisor goroutine that restarts workers. Each call returns a new channel that
+// receives all subsequent panics. Delivery never blocks the recovering goroutine: when the
+// channel's buffer is full the panic is dropped for that channel, so receivers should keep up.
+//
+// Example usage:
+//
+//	events := panics.NotifyChan()
+//	go func() {
+//	    for pe := range events {
+//	        log.Printf("worker panicked: %v", pe.Value)
+//	        restartWorker()
+//	    }
+//	}()
+func NotifyChan() <-chan PanicError {
+	ch := make(chan PanicError, notifyBuffer)
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	chans := make([]chan PanicError, len(notifyChans), len(notifyChans)+1)
+	copy(chans, notifyChans)
+	notifyChans = append(chans, ch)
+	return ch
+}
+
+// recovered converts a value returned by recover into a *PanicError and runs the registered
+// hooks. It must be called from the deferred function that recovered. A value that is already
+// a *PanicError, e.g. from WithTrace or re-panicked after an inner recovery, is returned
+// unchanged so the original stack is kept, and the hooks only run the first time it is
+// recovered. Any other value is new to the package, so the hooks run again for a value that
+// RecoverAndRepanic, Finalize or a Recoverer re-panicked.
+func recovered(r any) *PanicError {
+	pe, ok := r.(*PanicError)
+	if !ok {
+		pe = newPanicError(r)
+	} else if pe.hooked {
+		return pe
+	}
+	pe.returned = false
+	pe.hooked = true
+	runHooks(pe)
+	return pe
+}
+
+// runHooks sends pe to every NotifyChan channel that has room and calls every registered
+// hook with it, recovering and logging panics in hooks.
+func runHooks(pe *PanicError) {
+	hooksMu.RLock()
+	hooks, chans := panicHooks, notifyChans
+	hooksMu.RUnlock()
+
+	for _, ch := range chans {
+		select {
+		case ch <- *pe:
+		default:
+		}
+	}
+
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger().Error("panic hook panicked", "error", r)
+				}
+			}()
+			hook(pe.Value, pe.Stack)
+		}()
+	}
+}
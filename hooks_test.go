@@ -0,0 +1,48 @@
+package panics_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+func TestOnPanicRepanic(t *testing.T) {
+	var calls atomic.Int32
+	panics.OnPanic(func(any, []byte) { calls.Add(1) })
+
+	tests := []struct {
+		name  string
+		fn    func()
+		calls int32
+	}{
+		{"RecoverAndRepanic", func() {
+			defer panics.RecoverAndRepanic()
+			panic("boom")
+		}, 2},
+		{"Finalize", func() {
+			defer panics.Finalize(nil, true)
+			panic("boom")
+		}, 2},
+		{"Recoverer", func() {
+			defer panics.NewRecoverer(panics.WithRethrow(func(any) bool { return true })).Recover()
+			panic("boom")
+		}, 2},
+		{"PanicError from Try", func() {
+			if err := panics.Try(func() { panic("boom") }); err != nil {
+				panic(err)
+			}
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			if err := panics.Try(tt.fn); err == nil {
+				t.Fatal("Try returned nil, want the re-panicked error")
+			}
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("hook ran %d times, want %d", got, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package panics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+)
+
+// packageLogger holds the logger set with SetLogger. When unset, slog.Default() is used.
+var packageLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used by Recover, Retry, RecoveryMiddleware and the other helpers
+// that log recovered panics. Passing nil disables logging entirely.
+//
+// Example usage:
+//
+//	panics.SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	packageLogger.Store(l)
+}
+
+// recoverLevel holds the level set with SetRecoverLogLevel.
+var recoverLevel slog.LevelVar
+
+func init() {
+	recoverLevel.Set(slog.LevelError)
+}
+
+// SetRecoverLogLevel sets the level at which recovered panics are logged by Recover,
+// RecoverAndHandle, RecoveryMiddleware and the other recovery helpers. It defaults to
+// slog.LevelError; lower it, e.g. to slog.LevelWarn, where recovered panics are expected and
+// shouldn't alert.
+func SetRecoverLogLevel(level slog.Level) {
+	recoverLevel.Set(level)
+}
+
+// TestReporter is the part of testing.TB used to fail a test when a panic is recovered, so
+// this package doesn't have to import testing.
+type TestReporter interface {
+	Errorf(format string, args ...any)
+}
+
+// testReporter holds the reporter set with SetTestReporter.
+var testReporter atomic.Pointer[TestReporter]
+
+// SetTestReporter makes recovered panics that would be logged, e.g. by Recover,
+// RecoveryMiddleware or Go, fail the test through r instead, so panics swallowed in tested code
+// paths don't go unnoticed. Passing nil restores logging. Most tests should use
+// panicstest.Register, which also unregisters the test when it ends.
+func SetTestReporter(r TestReporter) {
+	if r == nil {
+		testReporter.Store(nil)
+		return
+	}
+	testReporter.Store(&r)
+}
+
+// reportToTest fails the test registered with SetTestReporter with pe, reporting whether one
+// was registered.
+func reportToTest(msg string, pe *PanicError) bool {
+	r := testReporter.Load()
+	if r == nil {
+		return false
+	}
+	(*r).Errorf("%s: %v\n%s", msg, pe, pe.Stack)
+	return true
+}
+
+// logger returns the logger set with SetLogger, or slog.Default() if none was set.
+func logger() *slog.Logger {
+	if l := packageLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// LogPanic logs a recovered panic through the package logger, in the same structured form as
+// the package's own recovery helpers. It is intended for integrations that recover panics
+// themselves, such as interceptors for other frameworks.
+func LogPanic(msg string, pe *PanicError, args ...any) {
+	logPanic(msg, pe, args...)
+}
+
+// LogPanicContext is like LogPanic, but passes ctx to the logger so handlers can add values
+// from it, such as trace IDs.
+func LogPanicContext(ctx context.Context, msg string, pe *PanicError, args ...any) {
+	logPanicTo(ctx, logger(), msg, pe, args...)
+}
+
+// logPanic logs a recovered panic with its error and stack trace as structured attributes,
+// followed by any extra key-value pairs in args.
+func logPanic(msg string, pe *PanicError, args ...any) {
+	logPanicTo(context.Background(), logger(), msg, pe, args...)
+}
+
+// logPanicTo is logPanic with an explicit context and logger. The context is passed to the
+// logger so handlers can add values from it, such as trace IDs. If a test reporter is
+// registered, the panic fails the test instead of being logged.
+func logPanicTo(ctx context.Context, l *slog.Logger, msg string, pe *PanicError, args ...any) {
+	if reportToTest(msg, pe) || !allowLog(ctx, l) {
+		return
+	}
+	l.Log(ctx, recoverLevel.Level(), msg, panicAttrs(pe, true, args)...)
+}
+
+// panicAttrs returns the attributes logged for pe: the error, with the panic value cut to the
+// SetMaxMessageLen limit, the stack trace if withStack is set, "runtime": true if the panic was
+// a runtime.Error such as a nil dereference, and then args.
+func panicAttrs(pe *PanicError, withStack bool, args []any) []any {
+	attrs := []any{"error", pe}
+	if maxMessageLen.Load() > 0 {
+		attrs[1] = "panic: " + truncate(pe.valueString())
+	}
+	if withStack {
+		attrs = append(attrs, "stack", string(pe.Stack))
+	}
+	var re runtime.Error
+	if errors.As(pe, &re) {
+		attrs = append(attrs, "runtime", true)
+	}
+	return append(attrs, args...)
+}
@@ -0,0 +1,50 @@
+package panics
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// maxMessageLen holds the limit set with SetMaxMessageLen; 0 means unlimited.
+var maxMessageLen atomic.Int64
+
+// SetMaxMessageLen caps the length, in runes, of the panic value logged for recovered panics
+// and of the message and detail of failed assertions. Longer text is cut and ends with "…".
+// A value of 0 or less, the default, means no limit. The stack trace is not affected.
+//
+// Example usage:
+//
+//	panics.SetMaxMessageLen(2000)
+func SetMaxMessageLen(n int) {
+	maxMessageLen.Store(int64(max(n, 0)))
+}
+
+// truncate cuts s to the limit set with SetMaxMessageLen, without splitting a multi-byte
+// character.
+func truncate(s string) string {
+	n := int(maxMessageLen.Load())
+	if n == 0 || len(s) <= n {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i] + "…"
+		}
+		count++
+	}
+	return s
+}
+
+// truncateDetail applies truncate to the formatted detail of an assertion. Without a limit the
+// detail is returned unchanged, so a custom message format still receives the original error.
+func truncateDetail(detail any) any {
+	if maxMessageLen.Load() == 0 || detail == nil {
+		return detail
+	}
+	s := fmt.Sprint(detail)
+	if t := truncate(s); t != s {
+		return t
+	}
+	return detail
+}
@@ -0,0 +1,263 @@
+package panics
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// requestIDFunc holds the extractor set with SetRequestIDFunc.
+var requestIDFunc atomic.Pointer[func(r *http.Request) string]
+
+// SetRequestIDFunc sets a function that extracts a request or trace ID from a request, e.g.
+// from its context. The recovery middleware includes the ID as the "request_id" log attribute
+// when it is not empty. Passing nil removes the extractor.
+//
+// Example usage:
+//
+//	panics.SetRequestIDFunc(func(r *http.Request) string {
+//	    id, _ := r.Context().Value(requestIDKey).(string)
+//	    return id
+//	})
+func SetRequestIDFunc(fn func(r *http.Request) string) {
+	if fn == nil {
+		requestIDFunc.Store(nil)
+		return
+	}
+	requestIDFunc.Store(&fn)
+}
+
+// RecoveryMiddleware is an HTTP middleware that recovers from panics in handlers,
+// logs the error and stack trace, and returns a 500 Internal Server Error response.
+// It is NewRecoveryMiddleware with no options.
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return NewRecoveryMiddleware()(next)
+}
+
+// RecoveryMiddlewareFunc returns an HTTP middleware that recovers from panics in handlers like
+// RecoveryMiddleware, and calls onPanic to write the response. It is shorthand for
+// NewRecoveryMiddleware(WithResponseRenderer(onPanic)).
+//
+// Example usage:
+//
+//	mw := RecoveryMiddlewareFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+//	    w.Header().Set("Content-Type", "application/json")
+//	    w.WriteHeader(http.StatusInternalServerError)
+//	    json.NewEncoder(w).Encode(map[string]string{"error": "internal", "request_id": requestID(r)})
+//	})
+//	http.Handle("/", mw(handler))
+func RecoveryMiddlewareFunc(onPanic func(w http.ResponseWriter, r *http.Request, err error)) func(http.Handler) http.Handler {
+	return NewRecoveryMiddleware(WithResponseRenderer(onPanic))
+}
+
+// NewRecoveryMiddleware returns an HTTP middleware configured by opts that recovers from panics
+// in handlers, logs the error and stack trace along with the request method, path and remote
+// address, and writes an error response. If the handler already started the response before
+// panicking, or hijacked the connection, no response is written since the status and headers
+// have been sent or there is no HTTP response left to write; the panic is only logged. A panic
+// with http.ErrAbortHandler is re-panicked so the server aborts the request as intended.
+//
+// Panics in the handler's own deferred calls are recovered as well, as they run before the
+// handler returns. Panics in goroutines started by the handler are not, since recover only
+// works on the panicking goroutine; start those with Go.
+//
+// Example usage:
+//
+//	mw := panics.NewRecoveryMiddleware(
+//	    panics.WithLogger(appLogger),
+//	    panics.WithMetricsHook(func(r *http.Request) {
+//	        panicCounter.WithLabelValues(r.URL.Path).Inc()
+//	    }),
+//	)
+//	http.Handle("/", mw(handler))
+func NewRecoveryMiddleware(opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				if rec := recover(); rec != nil {
+					if rec == http.ErrAbortHandler {
+						// Deliberate abort: let the server handle it silently.
+						panic(rec)
+					}
+					pe := recovered(rec)
+					storePanic(r.Context(), pe)
+					attrs := append(requestAttrs(r), "response_started", rw.started)
+					if rw.hijacked {
+						attrs = append(attrs, "hijacked", true)
+					}
+					if o.allStacks {
+						attrs = append(attrs, "goroutines", string(allStacks()))
+					}
+					o.logPanic(r.Context(), "recovered from panic", pe, attrs...)
+					if o.metricsHook != nil {
+						o.metricsHook(r)
+					}
+					if o.panicHook != nil {
+						o.panicHook(r, pe)
+					}
+					if o.hook != nil {
+						o.hook(pe)
+					}
+					switch {
+					case rw.started || rw.hijacked:
+						// Too late for a response: the panic has only been logged.
+					case o.fallback != nil:
+						fw := &responseWriter{ResponseWriter: w}
+						if err := Try(func() { o.fallback.ServeHTTP(fw, r) }); err != nil {
+							o.logPanic(r.Context(), "recovered from panic in fallback handler", err.(*PanicError), requestAttrs(r)...)
+							if !fw.started && !fw.hijacked {
+								o.renderer(w, r, pe)
+							}
+						}
+					default:
+						o.renderer(w, r, pe)
+					}
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// contextKey is the type of the context keys of this package, like net/http's.
+type contextKey struct {
+	name string
+}
+
+// PanicContextKey is the context key under which WithPanicContext stores the slot that
+// RecoveryMiddleware fills with a recovered panic. Use PanicFromContext to read it.
+var PanicContextKey = &contextKey{"panic"}
+
+// WithPanicContext returns a copy of ctx with an empty slot for a recovered panic, for
+// middleware that wraps RecoveryMiddleware and wants to know whether the request panicked,
+// such as an access log. Pass the returned context down with the request and read the slot
+// with PanicFromContext once the inner handler returns.
+//
+// Example usage:
+//
+//	func accessLog(next http.Handler) http.Handler {
+//	    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	        r = r.WithContext(panics.WithPanicContext(r.Context()))
+//	        next.ServeHTTP(w, r)
+//	        pe, panicked := panics.PanicFromContext(r.Context())
+//	        slog.Info("request", "path", r.URL.Path, "panicked", panicked, "panic", pe)
+//	    })
+//	}
+//
+//	http.Handle("/", accessLog(panics.RecoveryMiddleware(handler)))
+func WithPanicContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, PanicContextKey, new(atomic.Pointer[PanicError]))
+}
+
+// PanicFromContext returns the panic recovered by RecoveryMiddleware for the request whose
+// context is ctx, and whether there was one. ctx must come from WithPanicContext.
+func PanicFromContext(ctx context.Context) (*PanicError, bool) {
+	slot, _ := ctx.Value(PanicContextKey).(*atomic.Pointer[PanicError])
+	if slot == nil {
+		return nil, false
+	}
+	pe := slot.Load()
+	return pe, pe != nil
+}
+
+// storePanic fills the slot added by WithPanicContext to ctx, if any.
+func storePanic(ctx context.Context, pe *PanicError) {
+	if slot, _ := ctx.Value(PanicContextKey).(*atomic.Pointer[PanicError]); slot != nil {
+		slot.Store(pe)
+	}
+}
+
+// HandlerFunc wraps a single handler with the same panic recovery as NewRecoveryMiddleware,
+// configured by opts, so individual handlers, such as third-party ones, can be protected
+// without wrapping the whole chain.
+//
+// Example usage:
+//
+//	mux.HandleFunc("/report", panics.HandlerFunc(thirdparty.ReportHandler))
+func HandlerFunc(fn http.HandlerFunc, opts ...Option) http.HandlerFunc {
+	return NewRecoveryMiddleware(opts...)(fn).ServeHTTP
+}
+
+// requestAttrs returns the log attributes identifying r.
+func requestAttrs(r *http.Request) []any {
+	attrs := []any{"method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr}
+	if fn := requestIDFunc.Load(); fn != nil {
+		if id := (*fn)(r); id != "" {
+			attrs = append(attrs, "request_id", id)
+		}
+	}
+	return attrs
+}
+
+// mappedStatusResponse returns a renderer like statusResponse with the status code picked by
+// statusFor from the panic value, or fallback when statusFor returns 0.
+func mappedStatusResponse(statusFor func(value any) int, fallback int) func(w http.ResponseWriter, r *http.Request, err error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		code := fallback
+		if pe, ok := err.(*PanicError); ok {
+			if c := statusFor(pe.Value); c != 0 {
+				code = c
+			}
+		}
+		statusResponse(code)(w, r, err)
+	}
+}
+
+// statusResponse returns a renderer that writes a plain text response with the given status
+// code and its status text.
+func statusResponse(code int) func(w http.ResponseWriter, r *http.Request, err error) {
+	return func(w http.ResponseWriter, _ *http.Request, _ error) {
+		http.Error(w, http.StatusText(code), code)
+	}
+}
+
+// responseWriter wraps an http.ResponseWriter to track whether the response has started,
+// i.e. whether a status code or body bytes have been sent to the client.
+type responseWriter struct {
+	http.ResponseWriter
+	started  bool
+	hijacked bool
+}
+
+// WriteHeader records that the response has started, unless code is informational (1xx).
+func (w *responseWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.started = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records that the response has started and writes b.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the underlying writer if it supports http.Flusher. Flushing sends the headers,
+// so the response counts as started.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.started = true
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection from the underlying writer, e.g. for WebSockets. After a
+// successful hijack no HTTP response can be written, so the middleware only logs panics. It
+// returns an error wrapping http.ErrNotSupported if the underlying writer can't be hijacked.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, brw, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for use by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,173 @@
+package panics_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+func TestRecoveryMiddlewarePanicInDefer(t *testing.T) {
+	panics.SetLogger(nil)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		code    int
+	}{
+		{
+			name: "defer after normal return",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				defer func() { panic("cleanup") }()
+			},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "defer while already panicking",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				defer func() { panic("cleanup") }()
+				panic("body")
+			},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "defer after nested recover",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				defer func() {
+					recover()
+					panic("again")
+				}()
+				panic("body")
+			},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "defer after response started",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				defer func() { panic("cleanup") }()
+				w.Write([]byte("ok"))
+			},
+			code: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("panic escaped the middleware: %v", r)
+					}
+				}()
+				panics.RecoveryMiddleware(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
+
+// TestRecoveryMiddlewarePanicInGoroutine runs a handler whose goroutine panics in a child
+// process, since recover can't catch that panic and it crashes the process.
+func TestRecoveryMiddlewarePanicInGoroutine(t *testing.T) {
+	if os.Getenv("PANICS_GOROUTINE_CRASH") == "1" {
+		handler := panics.RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				panic("goroutine boom")
+			}()
+			<-done
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRecoveryMiddlewarePanicInGoroutine$")
+	cmd.Env = append(os.Environ(), "PANICS_GOROUTINE_CRASH=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("process exited cleanly, want a crash from the goroutine panic")
+	}
+	if !strings.Contains(string(out), "panic: goroutine boom") {
+		t.Errorf("output does not show the goroutine panic:\n%s", out)
+	}
+}
+
+func TestRecoveryMiddlewareAllGoroutines(t *testing.T) {
+	var buf bytes.Buffer
+	var stack []byte
+	mw := panics.NewRecoveryMiddleware(
+		panics.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		panics.WithAllGoroutines(true),
+		panics.WithHook(func(err error) {
+			var pe *panics.PanicError
+			if errors.As(err, &pe) {
+				stack = pe.Stack
+			}
+		}),
+	)
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.Contains(buf.String(), "goroutines=") {
+		t.Errorf("log has no goroutines attribute: %s", buf.String())
+	}
+	if len(stack) == 0 || strings.Contains(string(stack), "\n\ngoroutine ") {
+		t.Errorf("PanicError.Stack isn't the panicking goroutine's stack:\n%s", stack)
+	}
+}
+
+func TestRecoveryMiddlewarePanickingFallback(t *testing.T) {
+	panics.SetLogger(nil)
+
+	tests := []struct {
+		name     string
+		fallback http.HandlerFunc
+		code     int
+	}{
+		{
+			name:     "panics before writing",
+			fallback: func(w http.ResponseWriter, r *http.Request) { panic("fallback") },
+			code:     http.StatusInternalServerError,
+		},
+		{
+			name: "panics after writing",
+			fallback: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("fallback")
+			},
+			code: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := panics.NewRecoveryMiddleware(panics.WithFallback(tt.fallback))
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("handler")
+			}))
+			rec := httptest.NewRecorder()
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("panic escaped the middleware: %v", r)
+					}
+				}()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+		})
+	}
+}
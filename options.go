@@ -0,0 +1,152 @@
+package panics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// Option configures the recovery behaviour of NewRecoveryMiddleware and NewRecoverer.
+type Option func(*options)
+
+// options holds the configuration built from Options.
+type options struct {
+	logger      *slog.Logger
+	metricsHook func(r *http.Request)
+	panicHook   func(r *http.Request, err error)
+	hook        func(err error)
+	rethrow     func(value any) bool
+	renderer    func(w http.ResponseWriter, r *http.Request, err error)
+	fallback    http.Handler
+	statusCode  int
+	statusFor   func(value any) int
+	stackTrace  bool
+	allStacks   bool
+}
+
+// newOptions applies opts over the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{statusCode: http.StatusInternalServerError, stackTrace: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.renderer == nil && o.statusFor != nil {
+		o.renderer = mappedStatusResponse(o.statusFor, o.statusCode)
+	}
+	if o.renderer == nil {
+		o.renderer = statusResponse(o.statusCode)
+	}
+	return o
+}
+
+// logPanic logs pe to the configured logger, or the package logger if none was set, with the
+// stack trace unless it was disabled with WithStackTrace. Like logPanicTo, it fails the test
+// registered with SetTestReporter instead, if any.
+func (o *options) logPanic(ctx context.Context, msg string, pe *PanicError, args ...any) {
+	if reportToTest(msg, pe) {
+		return
+	}
+	l := o.logger
+	if l == nil {
+		l = logger()
+	}
+	if !allowLog(ctx, l) {
+		return
+	}
+	l.Log(ctx, recoverLevel.Level(), msg, panicAttrs(pe, o.stackTrace, args)...)
+}
+
+// WithLogger sets the logger used to log recovered panics instead of the package logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithMetricsHook sets a function called with the request every time a panic is recovered,
+// before the response is written, e.g. to increment a panic counter labelled by route.
+func WithMetricsHook(fn func(r *http.Request)) Option {
+	return func(o *options) {
+		o.metricsHook = fn
+	}
+}
+
+// WithPanicHook sets a function called with the request and the recovered *PanicError every
+// time a panic is recovered, before the response is written.
+func WithPanicHook(fn func(r *http.Request, err error)) Option {
+	return func(o *options) {
+		o.panicHook = fn
+	}
+}
+
+// WithHook sets a function called with the recovered *PanicError every time a panic is
+// recovered, e.g. to record a metric. In the middleware it is called after WithPanicHook.
+func WithHook(fn func(err error)) Option {
+	return func(o *options) {
+		o.hook = fn
+	}
+}
+
+// WithRethrow sets a predicate called with the recovered value, after logging and hooks, that
+// decides whether a Recoverer panics again with it, e.g. for runtime.Error panics that should
+// still crash the process. It has no effect on the middleware.
+func WithRethrow(pred func(value any) bool) Option {
+	return func(o *options) {
+		o.rethrow = pred
+	}
+}
+
+// WithStatusCode sets the status code of the default plain text error response. It defaults
+// to 500 Internal Server Error and has no effect when WithResponseRenderer is used.
+func WithStatusCode(code int) Option {
+	return func(o *options) {
+		o.statusCode = code
+	}
+}
+
+// WithStatusMapper sets a function that maps the recovered panic value to the status code of
+// the default plain text error response, so typed panics such as a validation error can
+// produce a 400. A code of 0 falls back to the WithStatusCode status, 500 by default. It has no
+// effect when WithResponseRenderer is used.
+func WithStatusMapper(fn func(value any) int) Option {
+	return func(o *options) {
+		o.statusFor = fn
+	}
+}
+
+// WithStackTrace sets whether the stack trace is included when logging recovered panics. It
+// defaults to true.
+func WithStackTrace(include bool) Option {
+	return func(o *options) {
+		o.stackTrace = include
+	}
+}
+
+// WithAllGoroutines sets whether the stack traces of all goroutines are logged, under the
+// "goroutines" attribute, when a panic is recovered. The *PanicError passed to hooks keeps the
+// stack of the goroutine that panicked. It defaults to false, as stopping the world to collect
+// every stack is expensive.
+func WithAllGoroutines(include bool) Option {
+	return func(o *options) {
+		o.allStacks = include
+	}
+}
+
+// WithResponseRenderer sets the function that writes the response after a panic. The error
+// passed to it is a *PanicError. It defaults to a plain text 500 Internal Server Error.
+func WithResponseRenderer(fn func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(o *options) {
+		o.renderer = fn
+	}
+}
+
+// WithFallback sets a handler that serves the request instead of the error response when a
+// panic is recovered before anything was written, e.g. to serve a cached page from a
+// non-critical endpoint. The panic is still logged. It takes precedence over
+// WithResponseRenderer, which is only used if h itself panics before writing anything; a panic
+// in h is logged too.
+func WithFallback(h http.Handler) Option {
+	return func(o *options) {
+		o.fallback = h
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/rizvn/panics/otelpanics
+
+go 1.25.0
+
+require (
+	github.com/rizvn/panics v0.0.0-20261014092257-4f606a7ee639
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -0,0 +1,68 @@
+// Package otelpanics records recovered panics on OpenTelemetry spans, so they show up in
+// distributed traces.
+//
+// It lives in its own module so that the base panics package stays free of the OpenTelemetry
+// dependency.
+package otelpanics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rizvn/panics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordPanicOnSpan records a recovered panic on span as an exception event, with the stack
+// trace as its exception.stacktrace attribute, and sets the span status to error. value is the
+// recovered value; a *panics.PanicError is recorded as it is, with its own stack when stack is
+// nil.
+//
+// Example usage:
+//
+//	defer func() {
+//	    if r := recover(); r != nil {
+//	        otelpanics.RecordPanicOnSpan(span, r, debug.Stack())
+//	    }
+//	}()
+func RecordPanicOnSpan(span trace.Span, value any, stack []byte) {
+	var pe *panics.PanicError
+	if err, ok := value.(error); !ok || !errors.As(err, &pe) {
+		pe = &panics.PanicError{Value: value, Stack: stack}
+	}
+	if stack == nil {
+		stack = pe.Stack
+	}
+	span.RecordError(pe, trace.WithAttributes(attribute.String("exception.stacktrace", string(stack))))
+	span.SetStatus(codes.Error, pe.Error())
+}
+
+// RecordPanic records err, a *panics.PanicError, on the span in ctx, if there is one that is
+// recording. It has the signature of a grpcpanics hook, so it can be installed on the gRPC
+// interceptors.
+//
+// Example usage:
+//
+//	grpc.UnaryInterceptor(grpcpanics.UnaryServerInterceptor(grpcpanics.WithHook(otelpanics.RecordPanic)))
+func RecordPanic(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	RecordPanicOnSpan(span, err, nil)
+}
+
+// PanicHook records panics recovered by the panics recovery middleware on the request's span.
+// Install it with panics.WithPanicHook behind an OpenTelemetry HTTP instrumentation that puts
+// the span in the request context.
+//
+// Example usage:
+//
+//	mw := panics.NewRecoveryMiddleware(panics.WithPanicHook(otelpanics.PanicHook))
+//	http.Handle("/", otelhttp.NewHandler(mw(handler), "server"))
+func PanicHook(r *http.Request, err error) {
+	RecordPanic(r.Context(), err)
+}
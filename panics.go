@@ -1,61 +1,740 @@
 package panics
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
-	"log/slog"
-	"net/http"
+	"reflect"
 	"runtime"
-	"runtime/debug"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-const errorFormat = "\nFile: %s \nLine: %d \nMessage: %s \nError: %v\n"
-
 // OnError panics if err is not nil, including an optional message and stack trace.
+// The panic value is an error wrapping err.
+//
+// Each On assertion has a Check counterpart, e.g. CheckError, that returns the same error
+// instead of panicking.
 func OnError(err error, message string) {
-	if err != nil {
-		_, file, line, _ := runtime.Caller(1)
-		panic(fmt.Sprintf(errorFormat, file, line, message, err))
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkError(1, err, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnErrors panics if any of errs is not nil, including an optional message, the index and
+// value of each failed error and stack trace. Nil entries are skipped, and the panic value
+// wraps every failed error.
+//
+// Example usage:
+//
+//	OnErrors("initialising store", dbErr, cacheErr, queueErr)
+func OnErrors(message string, errs ...error) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkErrors(1, message, errs); err != nil {
+		panic(err)
+	}
+}
+
+// OnAnyError panics if any of errs is not nil, with the non-nil errors combined by errors.Join,
+// including an optional message and stack trace. Unlike OnErrors, the errors are reported as
+// they are, without their index, which suits the []error returned by bulk operations. The
+// panic value wraps the joined error, so errors.Is matches any of them after recovery.
+//
+// Example usage:
+//
+//	OnAnyError("bulk insert", store.InsertAll(rows))
+func OnAnyError(message string, errs []error) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkError(1, errors.Join(errs...), message); err != nil {
+		panic(err)
+	}
+}
+
+// OnUnexpectedError panics like OnError if err is not nil and does not match any of allowed
+// with errors.Is, so expected sentinels such as io.EOF pass through. The panic value wraps err.
+//
+// Example usage:
+//
+//	OnUnexpectedError(row.Scan(&u.ID), "loading user", sql.ErrNoRows)
+func OnUnexpectedError(err error, message string, allowed ...error) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkUnexpectedError(1, err, message, allowed); err != nil {
+		panic(err)
 	}
 }
 
 // OnNil panics if value is nil, including an optional message and stack trace.
+// A nil pointer, map, slice, channel, func or interface stored in value, such as a
+// (*T)(nil) passed as any, is also treated as nil.
 func OnNil(value any, message string) {
-	if value == nil {
-		_, file, line, _ := runtime.Caller(1)
-		panic(fmt.Sprintf(errorFormat, file, line, message, "nil value"))
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNil(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnNilChan panics if ch is a nil channel, including an optional message and stack trace.
+// Sending on or receiving from a nil channel blocks forever, so this catches an uninitialised
+// channel where it is handed over rather than where it hangs. It also panics if ch is not a
+// channel of any direction.
+//
+// Example usage:
+//
+//	OnNilChan(w.events, "events channel")
+func OnNilChan(ch any, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNilKind(1, ch, reflect.Chan, message, "nil channel"); err != nil {
+		panic(err)
+	}
+}
+
+// OnNilFunc panics if fn is a nil func, including an optional message and stack trace. It also
+// panics if fn is not a func.
+//
+// Example usage:
+//
+//	OnNilFunc(opts.OnEvent, "OnEvent callback")
+func OnNilFunc(fn any, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNilKind(1, fn, reflect.Func, message, "nil func"); err != nil {
+		panic(err)
 	}
 }
 
 // OnFalse panics if condition is false, including an optional message and stack trace.
 func OnFalse(condition bool, message string) {
-	if !condition {
-		_, file, line, _ := runtime.Caller(1)
-		panic(fmt.Sprintf(errorFormat, file, line, message, ""))
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkFalse(1, condition, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnTrue panics if condition is true, including an optional message and stack trace. It reads
+// better than OnFalse with a negated condition.
+//
+// Example usage:
+//
+//	OnTrue(user.Banned, "banned user reached checkout")
+func OnTrue(condition bool, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkTrue(1, condition, message); err != nil {
+		panic(err)
 	}
 }
 
 // OnBlank panics if the string value is blank (empty or whitespace), including an optional message and stack trace.
 func OnBlank(value string, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkBlank(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnInvalidUTF8 panics if value is not valid UTF-8, including an optional message, the byte
+// offset of the first invalid sequence and stack trace.
+//
+// Example usage:
+//
+//	OnInvalidUTF8(msg.Body, "message body")
+func OnInvalidUTF8(value string, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkInvalidUTF8(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnShorterThan panics if value has fewer than min runes, including an optional message, the
+// actual length and stack trace. Runes rather than bytes are counted, which is what matters for
+// user-facing text.
+//
+// Example usage:
+//
+//	OnShorterThan(password, 12, "password too short")
+func OnShorterThan(value string, min int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkShorterThan(1, value, min, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnLongerThan panics if value has more than max runes, including an optional message, the
+// actual length and stack trace.
+//
+// Example usage:
+//
+//	OnLongerThan(user.DisplayName, 64, "display name too long")
+func OnLongerThan(value string, max int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkLongerThan(1, value, max, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnErrorSkip is like OnError, but attributes the failure to the caller skip frames above the
+// direct caller, so thin wrappers can report their own caller's file and line. A skip of 0
+// behaves like OnError.
+//
+// Example usage:
+//
+//	func mustValidate(err error) {
+//	    panics.OnErrorSkip(err, 1, "validation failed") // reports the caller of mustValidate
+//	}
+func OnErrorSkip(err error, skip int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkError(skip+1, err, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnNilSkip is like OnNil, with the caller frame chosen by skip as in OnErrorSkip.
+func OnNilSkip(value any, skip int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNil(skip+1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnFalseSkip is like OnFalse, with the caller frame chosen by skip as in OnErrorSkip.
+func OnFalseSkip(condition bool, skip int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkFalse(skip+1, condition, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnBlankSkip is like OnBlank, with the caller frame chosen by skip as in OnErrorSkip.
+func OnBlankSkip(value string, skip int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkBlank(skip+1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnErrorf is like OnError, but builds the message from format and args. The message is only
+// formatted when err is not nil.
+func OnErrorf(err error, format string, args ...any) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err != nil {
+		panic(newAssertionError(1, fmt.Sprintf(format, args...), err))
+	}
+}
+
+// OnErrorLazy is like OnError, but only calls messageFn to build the message when err is not
+// nil. When err is nil it returns without allocating, so it is suitable for assertions in tight
+// loops where building the message every time would be wasteful.
+//
+// Example usage:
+//
+//	for i, row := range rows {
+//	    OnErrorLazy(row.Err, func() string { return fmt.Sprintf("row %d", i) })
+//	}
+func OnErrorLazy(err error, messageFn func() string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err != nil {
+		panic(newAssertionError(1, messageFn(), err))
+	}
+}
+
+// OnNilf is like OnNil, but builds the message from format and args. The message is only
+// formatted when value is nil.
+func OnNilf(value any, format string, args ...any) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if isNil(value) {
+		panic(newAssertionError(1, fmt.Sprintf(format, args...), "nil value"))
+	}
+}
+
+// OnFalsef is like OnFalse, but builds the message from format and args. The message is only
+// formatted when condition is false.
+func OnFalsef(condition bool, format string, args ...any) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if !condition {
+		panic(newAssertionError(1, fmt.Sprintf(format, args...), nil))
+	}
+}
+
+// OnBlankf is like OnBlank, but builds the message from format and args. The message is only
+// formatted when value is blank.
+func OnBlankf(value string, format string, args ...any) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
 	if strings.TrimSpace(value) == "" {
-		_, file, line, _ := runtime.Caller(1)
-		panic(fmt.Sprintf(errorFormat, file, line, message, "blank string"))
+		panic(newAssertionError(1, fmt.Sprintf(format, args...), "blank string"))
+	}
+}
+
+// OnEmpty panics if the slice has no elements (including a nil slice), including an optional
+// message and stack trace.
+func OnEmpty[T any](collection []T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkLen(1, len(collection), message, "empty slice"); err != nil {
+		panic(err)
+	}
+}
+
+// OnEmptyMap panics if the map has no entries (including a nil map), including an optional
+// message and stack trace.
+func OnEmptyMap[K comparable, V any](collection map[K]V, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkLen(1, len(collection), message, "empty map"); err != nil {
+		panic(err)
+	}
+}
+
+// OnEmptyString panics if the string has zero length, including an optional message and stack
+// trace. Unlike OnBlank, a string of only whitespace is not considered empty.
+func OnEmptyString(value string, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkLen(1, len(value), message, "empty string"); err != nil {
+		panic(err)
+	}
+}
+
+// OnLenNot panics if the slice does not have exactly expected elements, including an optional
+// message, the expected and actual length and stack trace.
+func OnLenNot[T any](s []T, expected int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkLenNot(1, len(s), expected, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnLenNotMap panics if the map does not have exactly expected entries, including an optional
+// message, the expected and actual length and stack trace.
+func OnLenNotMap[K comparable, V any](m map[K]V, expected int, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkLenNot(1, len(m), expected, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnMissingKey panics if key is not present in m, including an optional message, the missing
+// key and stack trace. Otherwise it returns the value stored under key.
+//
+// Example usage:
+//
+//	db := OnMissingKey(cfg, "db", "db config")
+func OnMissingKey[K comparable, V any](m map[K]V, key K, message string) V {
+	if !assertionsCompiled || !enabled() {
+		return m[key]
+	}
+	v, err := checkMissingKey(1, m, key, message)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// OnDuplicate panics if any element of s appears more than once, including an optional
+// message, the first duplicate found and stack trace.
+//
+// Example usage:
+//
+//	OnDuplicate(flagNames, "flag names must be unique")
+func OnDuplicate[T comparable](s []T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkDuplicate(1, s, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnZero panics if value is the zero value for its type (0, "", false, a zero struct, ...),
+// including an optional message and stack trace.
+func OnZero[T comparable](value T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkZero(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnEqual panics if a equals b, including an optional message, both values and stack trace.
+func OnEqual[T comparable](a, b T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkEqual(1, a, b, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnNotEqual panics if a differs from b, including an optional message, both values and stack
+// trace.
+func OnNotEqual[T comparable](a, b T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNotEqual(1, a, b, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnNegative panics if value is below zero, including an optional message, the offending value
+// and stack trace.
+func OnNegative[T SignedNumber](value T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNegative(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnOutOfRange panics if value is outside the inclusive range [min, max], including an optional
+// message, the offending value, the bounds and stack trace. A NaN value is never in range.
+func OnOutOfRange[T cmp.Ordered](value, min, max T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkOutOfRange(1, value, min, max, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnNotType panics if value is not a T, including an optional message, the expected and actual
+// dynamic type and stack trace. Otherwise it returns value as a T, like a checked type
+// assertion.
+//
+// Example usage:
+//
+//	cfg := OnNotType[*Config](v, "plugin config")
+func OnNotType[T any](value any, message string) T {
+	if !assertionsCompiled || !enabled() {
+		v, _ := value.(T)
+		return v
+	}
+	v, err := checkNotType[T](1, value, message)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// OnNaN panics if value is NaN, e.g. the result of 0/0, including an optional message and stack
+// trace.
+//
+// Example usage:
+//
+//	OnNaN(mean, "mean of empty sample")
+func OnNaN[T Float](value T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNaN(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnInf panics if value is positive or negative infinity, e.g. after a division by zero or an
+// overflow, including an optional message, the value and stack trace.
+//
+// Example usage:
+//
+//	OnInf(ratio, "ratio overflowed")
+func OnInf[T Float](value T, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkInf(1, value, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnNegativeDuration panics if d is below zero, including an optional message, the offending
+// duration and stack trace.
+//
+// Example usage:
+//
+//	OnNegativeDuration(cfg.Timeout, "timeout must not be negative")
+func OnNegativeDuration(d time.Duration, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkNegativeDuration(1, d, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnZeroTime panics if t is the zero time, e.g. a deadline that was never set, including an
+// optional message and stack trace.
+//
+// Example usage:
+//
+//	OnZeroTime(job.Deadline, "job deadline not set")
+func OnZeroTime(t time.Time, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkZeroTime(1, t, message); err != nil {
+		panic(err)
+	}
+}
+
+// OnContextDone panics if ctx is cancelled or past its deadline, including an optional message,
+// the context's error and stack trace. The panic value wraps ctx.Err(), so errors.Is(err,
+// context.Canceled) keeps working. A nil ctx panics too.
+//
+// Example usage:
+//
+//	func (w *Worker) flush(ctx context.Context) {
+//	    OnContextDone(ctx, "flush called after shutdown")
+//	    ...
+//	}
+func OnContextDone(ctx context.Context, message string) {
+	if !assertionsCompiled || !enabled() {
+		return
+	}
+	if err := checkContextDone(1, ctx, message); err != nil {
+		panic(err)
+	}
+}
+
+// Must returns value if err is nil, otherwise it panics with the caller's file and line like OnError.
+//
+// Example usage:
+//
+//	f := Must(os.Open("config.json"))
+func Must[T any](value T, err error) T {
+	if err := checkError(1, err, ""); err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Must2 is like Must for functions that return two values and an error.
+//
+// Example usage:
+//
+//	host, port := Must2(net.SplitHostPort(addr))
+func Must2[T1, T2 any](value1 T1, value2 T2, err error) (T1, T2) {
+	if err := checkError(1, err, ""); err != nil {
+		panic(err)
+	}
+	return value1, value2
+}
+
+// MustDo runs fn and panics like OnError if it returns an error. It is the counterpart of Must
+// for side-effecting initialisers that only return an error.
+//
+// Example usage:
+//
+//	MustDo(func() error { return db.Ping() })
+func MustDo(fn func() error) {
+	if err := checkError(1, fn(), ""); err != nil {
+		panic(err)
 	}
 }
 
-// WithTrace panics with the provided message and a stack trace.
+// NewTraceError returns a *PanicError holding message and the stack trace captured where
+// NewTraceError is called, without panicking. It lets error-returning code produce the same
+// stack-carrying errors as WithTrace. Since nothing panicked, the error doesn't match ErrPanic
+// and its message has no "panic: " prefix, unless it is later panicked with and recovered.
+//
+// Example usage:
+//
+//	if state == unknown {
+//	    return NewTraceError("unexpected state")
+//	}
+func NewTraceError(message string) error {
+	pe := newPanicError(message)
+	pe.returned = true
+	return pe
+}
+
+// WithTrace panics with the provided message and a stack trace. The panic value is a
+// *PanicError holding the message and the stack.
 func WithTrace(message string) {
-	panic(fmt.Errorf("panic: %s\nStacktrace: %s\n---", message, debug.Stack()))
+	panic(newPanicError(message))
+}
+
+// WithTracef panics with a message formatted from format and args, and a stack trace.
+func WithTracef(format string, args ...any) {
+	panic(newPanicError(fmt.Sprintf(format, args...)))
+}
+
+// WithTraceErr panics with err and a stack trace. The panic value is a *PanicError wrapping
+// err, so errors.Is and errors.As still see the original error after recovery.
+func WithTraceErr(err error) {
+	panic(newPanicError(err))
+}
+
+// WithTraceDepth panics with the provided message and a trimmed stack trace: frames of the
+// panics package itself are left out and at most maxFrames frames are included. A maxFrames of
+// zero or less includes the whole stack.
+func WithTraceDepth(message string, maxFrames int) {
+	pe := newPanicError(message)
+	pe.Frames = callers(1, maxFrames)
+	pe.Stack = formatFrames(pe.Frames)
+	panic(pe)
+}
+
+// WithTraceAll panics with the provided message and the stack traces of every goroutine, not
+// just the calling one, to debug panics caused by another goroutine being stuck. Stopping the
+// world to collect all stacks is expensive, so keep it for rare paths.
+//
+// Example usage:
+//
+//	case <-time.After(time.Minute):
+//	    WithTraceAll("worker pool wedged")
+func WithTraceAll(message string) {
+	pe := newPanicError(message)
+	pe.Stack = allStacks()
+	panic(pe)
+}
+
+// defaultHandler holds the handler set with SetDefaultHandler.
+var defaultHandler atomic.Pointer[func(err error)]
+
+// SetDefaultHandler sets the handler that Recover and RecoverWith, and the helpers built on them
+// such as Go and GoWait, call with each recovered panic. The error is a *PanicError. By default the panic is
+// logged with its stack trace; passing nil restores that behaviour.
+//
+// Example usage:
+//
+//	panics.SetDefaultHandler(func(err error) {
+//	    panicCounter.Inc()
+//	    slog.Error("recovered from panic", "error", err)
+//	})
+func SetDefaultHandler(fn func(err error)) {
+	if fn == nil {
+		defaultHandler.Store(nil)
+		return
+	}
+	defaultHandler.Store(&fn)
+}
+
+// handleDefault passes pe to the handler set with SetDefaultHandler, or logs it with msg.
+func handleDefault(msg string, pe *PanicError) {
+	if fn := defaultHandler.Load(); fn != nil {
+		(*fn)(pe)
+		return
+	}
+	logPanic(msg, pe)
 }
 
-// Recover is a helper to recover from panics and log the error and stack trace.
+// Recover is a helper to recover from panics and log the error and stack trace, or pass it to
+// the handler set with SetDefaultHandler.
 func Recover() {
 	if r := recover(); r != nil {
-		if err, ok := r.(error); ok {
-			slog.Error("Recovered from panic", "error", err)
-		} else {
-			slog.Error("Recovered from panic", "error", r)
-		}
+		handleDefault("Recovered from panic", recovered(r))
+	}
+}
+
+// RecoverWith is like Recover, but prefixes the log message with contextMsg, e.g. the name of
+// the subsystem, so recoveries from different goroutines can be told apart. Like Recover, it
+// passes the panic to the handler set with SetDefaultHandler instead of logging it, if there
+// is one. It must be deferred directly.
+//
+// Example usage:
+//
+//	go func() {
+//	    defer RecoverWith("order-processor")
+//	    processOrders()
+//	}()
+func RecoverWith(contextMsg string) {
+	if r := recover(); r != nil {
+		handleDefault(contextMsg+": Recovered from panic", recovered(r))
+	}
+}
+
+// RecoverValue recovers from a panic and stores the panic value in *value, leaving it untouched
+// when there was no panic. Nothing is logged, so callers can build their own recovery logic.
+// Because recover only works when called directly by a deferred function, RecoverValue takes a
+// pointer instead of returning the value, and must itself be deferred.
+//
+// Example usage:
+//
+//	func run() (err error) {
+//	    var r any
+//	    defer func() {
+//	        if r != nil {
+//	            err = fmt.Errorf("run panicked: %v", r)
+//	        }
+//	    }()
+//	    defer RecoverValue(&r)
+//	    mayPanic()
+//	    return nil
+//	}
+func RecoverValue(value *any) {
+	if r := recover(); r != nil {
+		recovered(r)
+		*value = r
+	}
+}
+
+// RecoverAndRepanic recovers from a panic, logs the error and stack trace, and then panics
+// again with the original value so a higher-level handler or the runtime still sees it. Hooks
+// registered with OnPanic run here and again in any outer recovery.
+//
+// Example usage:
+//
+//	go func() {
+//	    defer RecoverAndRepanic()
+//	    // code whose panics must still crash the process, but logged first
+//	}()
+func RecoverAndRepanic() {
+	if r := recover(); r != nil {
+		logPanic("Recovered from panic, re-panicking", recovered(r))
+		panic(r)
 	}
 }
 
@@ -69,6 +748,7 @@ func AddErrorInfo(err error) error {
 }
 
 // RecoverAndHandle recovers from a panic and passes the error to the provided handler function.
+// The error is a *PanicError carrying the panic value and the stack trace.
 //
 // Example usage:
 //
@@ -83,39 +763,44 @@ func AddErrorInfo(err error) error {
 //	}
 func RecoverAndHandle(fn func(err error)) {
 	if r := recover(); r != nil {
-		if err, ok := r.(error); ok {
-			fn(err)
-		} else {
-			fn(fmt.Errorf("recovered from panic: %v", r))
-		}
+		fn(recovered(r))
 	}
 }
 
-// Retry executes the provided function, retrying up to maxRetries times if it panics.
+// RecoverAndHandleWithStack recovers from a panic and passes the error, along with the stack
+// trace captured at recovery, to the provided handler function.
 //
 // Example usage:
 //
-//	Retry(3, func() {
-//	    // code that may panic
-//	    fmt.Println("Trying...")
-//	    panic("fail")
+//	defer RecoverAndHandleWithStack(func(err error, stack []byte) {
+//	    reporter.Report(err.Error(), stack)
 //	})
-func Retry(maxRetries int, fn func()) {
-	retries := maxRetries
-	for retries > 0 {
-		err := Try(fn)
-
-		if err == nil {
-			return
-		}
+func RecoverAndHandleWithStack(fn func(err error, stack []byte)) {
+	if r := recover(); r != nil {
+		pe := recovered(r)
+		fn(pe, pe.Stack)
+	}
+}
 
-		slog.Error("Retrying function due to error", "error", err)
-		retries--
+// RecoverTo recovers from a panic and stores it in *errp as a *PanicError with the stack
+// trace, so a function with a named error result returns the panic as an error. Nothing is
+// logged, and *errp is left untouched when there was no panic. It must be deferred directly.
+//
+// Example usage:
+//
+//	func load(path string) (cfg *Config, err error) {
+//	    defer RecoverTo(&err)
+//	    return parse(Must(os.ReadFile(path))), nil
+//	}
+func RecoverTo(errp *error) {
+	if r := recover(); r != nil {
+		*errp = recovered(r)
 	}
 }
 
 // Try executes the provided function and returns an error if it panics.
-// It uses RecoverAndHandle to capture any panic as an error.
+// It uses RecoverAndHandle to capture any panic as a *PanicError, which keeps the stack
+// trace of the panic.
 //
 // Example usage:
 //
@@ -126,33 +811,120 @@ func Retry(maxRetries int, fn func()) {
 //	if err != nil {
 //	    fmt.Println("Recovered error:", err)
 //	}
-func Try(fn func()) error {
-	var err error
-	func() {
-		// Use a deferred function to recover from panic and handle retries
-		defer RecoverAndHandle(func(err2 error) {
-			err = err2
-		})
-
-		fn()
-	}()
-	return err
-}
-
-// RecoveryMiddleware is an HTTP middleware that recovers from panics in handlers,
-// logs the error and stack trace, and returns a 500 Internal Server Error response.
-func RecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				if err, ok := rec.(error); ok {
-					slog.Error("recovered from panic", "error", err)
-				} else {
-					slog.Error("recovered from panic", "error", r)
-				}
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			}
-		}()
-		next.ServeHTTP(w, r)
+func Try(fn func()) (err error) {
+	defer RecoverAndHandle(func(err2 error) {
+		err = err2
 	})
+
+	fn()
+	return nil
+}
+
+// TryContext is like Try, but also logs a recovered panic with its stack trace, passing ctx to
+// the logger so context values such as trace IDs reach the log handler.
+//
+// Example usage:
+//
+//	err := TryContext(r.Context(), func() {
+//	    process(order)
+//	})
+func TryContext(ctx context.Context, fn func()) (err error) {
+	defer RecoverAndHandle(func(err2 error) {
+		logPanicTo(ctx, logger(), "Recovered from panic", err2.(*PanicError))
+		err = err2
+	})
+
+	fn()
+	return nil
+}
+
+// TryResult executes the provided function and returns its result, or the zero value and an
+// error if it panics. The error is a *PanicError carrying the original panic value and the
+// stack trace captured at the point of recovery.
+//
+// Example usage:
+//
+//	n, err := TryResult(func() int {
+//	    return mustParse(s)
+//	})
+//	if err != nil {
+//	    fmt.Println("Recovered error:", err)
+//	}
+func TryResult[T any](fn func() T) (result T, err error) {
+	defer RecoverAndHandle(func(err2 error) {
+		var zero T
+		result, err = zero, err2
+	})
+
+	return fn(), nil
+}
+
+// TryErr executes a function that returns an error. It returns the function's own error when
+// it returns normally, or a *PanicError when it panics. A panic takes precedence over any
+// error the function would have returned.
+//
+// Example usage:
+//
+//	err := TryErr(func() error {
+//	    return process(order)
+//	})
+func TryErr(fn func() error) (err error) {
+	defer RecoverAndHandle(func(err2 error) {
+		err = err2
+	})
+
+	return fn()
+}
+
+// TryMap applies fn to each element of in, in order, and returns the results. If fn panics, it
+// stops and returns the results for the elements before the one that panicked, and an error
+// naming that element's index and wrapping the *PanicError.
+//
+// Example usage:
+//
+//	parsed, err := TryMap(lines, parseLine)
+//	if err != nil {
+//	    fmt.Printf("parsed %d of %d lines: %v\n", len(parsed), len(lines), err)
+//	}
+func TryMap[T, U any](in []T, fn func(T) U) ([]U, error) {
+	out := make([]U, 0, len(in))
+	for i, v := range in {
+		u, err := TryResult(func() U { return fn(v) })
+		if err != nil {
+			return out, fmt.Errorf("element %d: %w", i, err)
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+// TryWithTimeout runs the provided function in a new goroutine and waits up to d for it to
+// finish. It returns nil on success, a *PanicError if the function panics, or an error
+// wrapping ErrTimeout if it does not finish within d.
+//
+// Go cannot kill a goroutine, so on timeout the function keeps running in the background;
+// only the caller is unblocked. A panic that happens after the timeout is still recovered.
+//
+// Example usage:
+//
+//	err := TryWithTimeout(5*time.Second, func() {
+//	    callUnreliableLibrary()
+//	})
+//	if errors.Is(err, ErrTimeout) {
+//	    fmt.Println("gave up waiting")
+//	}
+func TryWithTimeout(d time.Duration, fn func()) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- Try(fn)
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("function did not finish within %v: %w", d, ErrTimeout)
+	}
 }
@@ -0,0 +1,76 @@
+// Package panicstest provides test helpers for code that panics. It is separate from the
+// panics package so that package does not import testing.
+package panicstest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+// Register makes panics recovered and logged by the panics package, e.g. by a deferred
+// panics.Recover in the code under test, fail t instead of passing silently. The registration
+// is global and removed when t ends, so tests calling Register must not run in parallel.
+//
+// Example usage:
+//
+//	func TestWorker(t *testing.T) {
+//	    panicstest.Register(t)
+//	    runWorker()
+//	}
+func Register(t testing.TB) {
+	panics.SetTestReporter(t)
+	t.Cleanup(func() {
+		panics.SetTestReporter(nil)
+	})
+}
+
+// AssertPanics fails the test if fn does not panic.
+//
+// Example usage:
+//
+//	panicstest.AssertPanics(t, func() {
+//	    panics.OnNil(nil, "value is required")
+//	})
+func AssertPanics(t testing.TB, fn func()) {
+	t.Helper()
+	if panicked, _ := run(fn); !panicked {
+		t.Errorf("expected panic, but function returned normally")
+	}
+}
+
+// AssertNotPanics fails the test if fn panics, reporting the panic value.
+func AssertNotPanics(t testing.TB, fn func()) {
+	t.Helper()
+	if panicked, value := run(fn); panicked {
+		t.Errorf("expected no panic, but got: %v", value)
+	}
+}
+
+// AssertPanicsWithValue fails the test if fn does not panic, or panics with a value that is
+// not deeply equal to want.
+func AssertPanicsWithValue(t testing.TB, want any, fn func()) {
+	t.Helper()
+	panicked, value := run(fn)
+	if !panicked {
+		t.Errorf("expected panic with %v, but function returned normally", want)
+		return
+	}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("expected panic with %v (%T), but got %v (%T)", want, want, value, value)
+	}
+}
+
+// run calls fn and reports whether it panicked and with which value.
+func run(fn func()) (panicked bool, value any) {
+	panicked = true
+	defer func() {
+		if panicked {
+			value = recover()
+		}
+	}()
+	fn()
+	panicked = false
+	return
+}
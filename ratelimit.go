@@ -0,0 +1,69 @@
+package panics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// logLimit holds the limiter set with SetLogRateLimit, or nil when logging is not limited.
+var logLimit atomic.Pointer[logLimiter]
+
+// SetLogRateLimit limits the logging of recovered panics and failed retry attempts to at most
+// n messages per interval, so a panic storm can't flood the logging pipeline. Messages over the
+// limit are dropped and counted; the count is logged as a "suppressed log messages" summary
+// with the first message of a later interval. An n or per of zero or less removes the limit,
+// which is the default.
+//
+// Example usage:
+//
+//	panics.SetLogRateLimit(100, time.Second)
+func SetLogRateLimit(n int, per time.Duration) {
+	if n <= 0 || per <= 0 {
+		logLimit.Store(nil)
+		return
+	}
+	logLimit.Store(&logLimiter{n: n, per: per})
+}
+
+// logLimiter allows n messages per fixed window of length per.
+type logLimiter struct {
+	n   int
+	per time.Duration
+
+	mu         sync.Mutex
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// allowLog reports whether a message may be logged to l under the limit set with
+// SetLogRateLimit. When a new window starts after messages were suppressed, it first logs how
+// many were.
+func allowLog(ctx context.Context, l *slog.Logger) bool {
+	lim := logLimit.Load()
+	if lim == nil {
+		return true
+	}
+	lim.mu.Lock()
+	now := time.Now()
+	var suppressed int
+	if now.Sub(lim.start) >= lim.per {
+		suppressed = lim.suppressed
+		lim.start, lim.count, lim.suppressed = now, 0, 0
+	}
+	allowed := lim.count < lim.n
+	if allowed {
+		lim.count++
+	} else {
+		lim.suppressed++
+	}
+	lim.mu.Unlock()
+
+	if suppressed > 0 {
+		l.Log(ctx, recoverLevel.Level(), "suppressed log messages", "count", suppressed)
+	}
+	return allowed
+}
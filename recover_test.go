@@ -0,0 +1,38 @@
+package panics_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+func TestDefaultHandler(t *testing.T) {
+	var got []error
+	panics.SetDefaultHandler(func(err error) { got = append(got, err) })
+	t.Cleanup(func() { panics.SetDefaultHandler(nil) })
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"Recover", func() {
+			defer panics.Recover()
+			panic("boom")
+		}},
+		{"RecoverWith", func() {
+			defer panics.RecoverWith("worker")
+			panic("boom")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			tt.fn()
+			var pe *panics.PanicError
+			if len(got) != 1 || !errors.As(got[0], &pe) || pe.Value != "boom" {
+				t.Errorf("default handler got %v, want one *PanicError with value boom", got)
+			}
+		})
+	}
+}
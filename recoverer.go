@@ -0,0 +1,48 @@
+package panics
+
+import "context"
+
+// Recoverer recovers panics with one set of options, so the logger, hooks and re-panic policy
+// are configured once instead of being spread over global settings. Build it with NewRecoverer
+// and defer its Recover method. It is safe for concurrent use.
+type Recoverer struct {
+	o *options
+}
+
+// NewRecoverer returns a Recoverer configured by opts. WithLogger, WithStackTrace, WithHook and
+// WithRethrow apply; the HTTP options are ignored.
+//
+// Example usage:
+//
+//	var rec = panics.NewRecoverer(
+//	    panics.WithLogger(appLogger),
+//	    panics.WithHook(func(err error) { panicCounter.Inc() }),
+//	    panics.WithRethrow(func(v any) bool {
+//	        _, ok := v.(runtime.Error)
+//	        return ok
+//	    }),
+//	)
+//
+//	go func() {
+//	    defer rec.Recover()
+//	    work()
+//	}()
+func NewRecoverer(opts ...Option) *Recoverer {
+	return &Recoverer{o: newOptions(opts)}
+}
+
+// Recover recovers from a panic, logs it, calls the hook set with WithHook and then panics
+// again with the original value if the WithRethrow predicate reports true for it. It must be
+// deferred directly.
+func (rc *Recoverer) Recover() {
+	if r := recover(); r != nil {
+		pe := recovered(r)
+		rc.o.logPanic(context.Background(), "Recovered from panic", pe)
+		if rc.o.hook != nil {
+			rc.o.hook(pe)
+		}
+		if rc.o.rethrow != nil && rc.o.rethrow(r) {
+			panic(r)
+		}
+	}
+}
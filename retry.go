@@ -0,0 +1,312 @@
+package panics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBackoff is the delay cap used by RetryWithBackoff when maxDelay is not positive.
+const DefaultMaxBackoff = 30 * time.Second
+
+// jitterRand is the shared random source used by RetryWithJitter, guarded by jitterMu.
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Retry executes the provided function, retrying up to maxRetries times if it panics.
+// It returns nil as soon as an attempt succeeds, or an error wrapping the error from the
+// last attempt when every attempt panics. A maxRetries below 1 runs fn once.
+//
+// Example usage:
+//
+//	err := Retry(3, func() {
+//	    // code that may panic
+//	    fmt.Println("Trying...")
+//	    panic("fail")
+//	})
+func Retry(maxRetries int, fn func()) error {
+	_, err := RetryCount(maxRetries, fn)
+	return err
+}
+
+// RetryCount is like Retry, but also returns the number of attempts made, e.g. for recording
+// a histogram of retries. Success on the second attempt returns (2, nil). At least one attempt
+// is always made.
+//
+// Example usage:
+//
+//	attempts, err := RetryCount(3, callFlakyService)
+//	retryHistogram.Observe(float64(attempts))
+func RetryCount(maxRetries int, fn func()) (attempts int, err error) {
+	return retry(context.Background(), maxRetries, retryOptions{}, fn)
+}
+
+// RetryWithBackoff executes the provided function, retrying up to maxRetries times if it panics,
+// and sleeps base * 2^(attempt-1) between attempts. The delay never exceeds maxDelay, or
+// DefaultMaxBackoff when maxDelay is not positive.
+//
+// Example usage:
+//
+//	err := RetryWithBackoff(5, 100*time.Millisecond, 5*time.Second, func() {
+//	    // code that may panic
+//	    callFlakyService()
+//	})
+func RetryWithBackoff(maxRetries int, base, maxDelay time.Duration, fn func()) error {
+	return RetryPolicy{MaxAttempts: maxRetries, Backoff: ExponentialBackoff(base, maxDelay)}.Run(context.Background(), fn)
+}
+
+// RetryWithJitter executes the provided function, retrying up to maxRetries times if it panics,
+// and sleeps a random duration in [0, base * 2^(attempt-1)] between attempts (full jitter).
+// The upper bound is capped at DefaultMaxBackoff. It is safe to call from concurrent goroutines.
+//
+// Example usage:
+//
+//	err := RetryWithJitter(5, 100*time.Millisecond, func() {
+//	    // code that may panic
+//	    callFlakyService()
+//	})
+func RetryWithJitter(maxRetries int, base time.Duration, fn func()) error {
+	return RetryPolicy{MaxAttempts: maxRetries, Backoff: func(attempt int) time.Duration {
+		jitterMu.Lock()
+		defer jitterMu.Unlock()
+		return jitteredDelay(jitterRand, base, attempt)
+	}}.Run(context.Background(), fn)
+}
+
+// RetryWithJitterSource behaves like RetryWithJitter but draws the random delays from src,
+// which makes the sleep durations reproducible. src is only used by this call and must not be
+// shared with other goroutines while it runs.
+func RetryWithJitterSource(src rand.Source, maxRetries int, base time.Duration, fn func()) error {
+	rnd := rand.New(src)
+	return RetryPolicy{MaxAttempts: maxRetries, Backoff: func(attempt int) time.Duration {
+		return jitteredDelay(rnd, base, attempt)
+	}}.Run(context.Background(), fn)
+}
+
+// RetryIf executes the provided function, retrying up to maxRetries times if it panics, but
+// only while shouldRetry reports true for the recovered error. When retries are exhausted, the
+// error wraps ErrRetriesExhausted and the last error; when shouldRetry returns false, the
+// rejected error is returned as it is.
+//
+// Example usage:
+//
+//	err := RetryIf(3, func(err error) bool {
+//	    var netErr net.Error
+//	    return errors.As(err, &netErr) && netErr.Timeout()
+//	}, func() {
+//	    callFlakyService()
+//	})
+func RetryIf(maxRetries int, shouldRetry func(err error) bool, fn func()) error {
+	return RetryPolicy{MaxAttempts: maxRetries, ShouldRetry: shouldRetry}.Run(context.Background(), fn)
+}
+
+// RetryWithContext executes the provided function, retrying up to maxRetries times if it panics,
+// and stops as soon as ctx is done. ctx is checked before every attempt, so fn is never run when
+// ctx is already cancelled; in that case the context error is returned. Failed attempts are
+// logged with ctx, so context values such as trace IDs reach the log handler.
+//
+// Example usage:
+//
+//	err := RetryWithContext(r.Context(), 3, func() {
+//	    // code that may panic
+//	    callFlakyService()
+//	})
+func RetryWithContext(ctx context.Context, maxRetries int, fn func()) error {
+	return RetryPolicy{MaxAttempts: maxRetries}.Run(ctx, fn)
+}
+
+// RetryUntil executes the provided function, retrying with a constant backoff between attempts
+// until it succeeds, the deadline is reached or ctx is done. It returns the last recovered error
+// when the deadline is reached, and the context error when ctx is done first.
+//
+// Example usage:
+//
+//	err := RetryUntil(ctx, time.Now().Add(30*time.Second), time.Second, func() {
+//	    // code that may panic
+//	    connect()
+//	})
+func RetryUntil(ctx context.Context, deadline time.Time, backoff time.Duration, fn func()) error {
+	return errOnly(retry(ctx, math.MaxInt, retryOptions{
+		delay:    ConstantBackoff(backoff),
+		deadline: deadline,
+	}, fn))
+}
+
+// RetryPolicy describes how to retry a function that panics, so a strategy can be defined once
+// and reused across call sites instead of picking among the RetryWith functions. A MaxAttempts
+// below 1 runs fn once, so the zero RetryPolicy runs fn once without retrying. The zero value of
+// Backoff retries immediately, and a nil ShouldRetry retries every error.
+//
+// Example usage:
+//
+//	var flaky = panics.RetryPolicy{
+//	    MaxAttempts: 5,
+//	    Backoff:     panics.ExponentialBackoff(100*time.Millisecond, 5*time.Second),
+//	    ShouldRetry: isTransient,
+//	}
+//
+//	err := flaky.Run(ctx, callFlakyService)
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times fn is run. Values below 1 mean 1.
+	MaxAttempts int
+	// Backoff returns how long to wait after the given failed attempt, counting from 1.
+	Backoff func(attempt int) time.Duration
+	// ShouldRetry reports whether a failed attempt should be retried.
+	ShouldRetry func(err error) bool
+}
+
+// Run executes fn, retrying up to p.MaxAttempts times if it panics and waiting p.Backoff
+// between attempts, and stops as soon as ctx is done. It returns nil as soon as an attempt
+// succeeds, the context error if ctx is done first, or an error wrapping ErrRetriesExhausted
+// and the last recovered error.
+func (p RetryPolicy) Run(ctx context.Context, fn func()) error {
+	return errOnly(retry(ctx, p.MaxAttempts, retryOptions{delay: p.Backoff, shouldRetry: p.ShouldRetry}, fn))
+}
+
+// ConstantBackoff returns a RetryPolicy backoff that always waits d.
+func ConstantBackoff(d time.Duration) func(attempt int) time.Duration {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// LinearBackoff returns a RetryPolicy backoff that waits step * attempt, capped at maxDelay, or
+// DefaultMaxBackoff when maxDelay is not positive.
+func LinearBackoff(step, maxDelay time.Duration) func(attempt int) time.Duration {
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxBackoff
+	}
+	return func(attempt int) time.Duration {
+		if step <= 0 {
+			return 0
+		}
+		if time.Duration(attempt) >= maxDelay/step {
+			return maxDelay
+		}
+		return step * time.Duration(attempt)
+	}
+}
+
+// ExponentialBackoff returns a RetryPolicy backoff that waits base * 2^(attempt-1), capped at
+// maxDelay, or DefaultMaxBackoff when maxDelay is not positive, as RetryWithBackoff does.
+func ExponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		return exponentialDelay(base, maxDelay, attempt)
+	}
+}
+
+// retryOptions configures a call to retry.
+type retryOptions struct {
+	// delay returns how long to sleep after a failed attempt. Nil retries immediately.
+	delay func(attempt int) time.Duration
+	// shouldRetry reports whether a failed attempt should be retried. Nil retries every error.
+	shouldRetry func(err error) bool
+	// deadline, when set, stops retrying once the next attempt would start after it.
+	deadline time.Time
+}
+
+// retry runs fn up to maxRetries times, and at least once, and reports how many attempts were made, sleeping for
+// opts.delay(attempt) after each failed attempt except the last, and stopping early when
+// opts.shouldRetry rejects an error or the opts.deadline would be passed. It returns ctx.Err()
+// as soon as ctx is done, either before an attempt or while sleeping. An error rejected by
+// opts.shouldRetry is returned as it is; other failures wrap ErrRetriesExhausted. A failed
+// attempt is logged as a retry only when another attempt follows; giving up is logged once.
+func retry(ctx context.Context, maxRetries int, opts retryOptions, fn func()) (attempts int, err error) {
+	maxRetries = max(maxRetries, 1)
+	for attempts < maxRetries {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return attempts, ctxErr
+		}
+
+		attempts++
+		err = Try(fn)
+
+		if err == nil {
+			return attempts, nil
+		}
+
+		if opts.shouldRetry != nil && !opts.shouldRetry(err) {
+			if l := logger(); allowLog(ctx, l) {
+				l.ErrorContext(ctx, "Not retrying function due to error", "attempt", attempts, "error", err)
+			}
+			return attempts, err
+		}
+
+		if attempts == maxRetries {
+			break
+		}
+		var d time.Duration
+		if opts.delay != nil {
+			d = opts.delay(attempts)
+		}
+		if !opts.deadline.IsZero() && time.Until(opts.deadline) <= d {
+			break
+		}
+
+		if l := logger(); allowLog(ctx, l) {
+			l.ErrorContext(ctx, "Retrying function due to error", "attempt", attempts, "error", err)
+		}
+		if ctxErr := sleep(ctx, d); ctxErr != nil {
+			return attempts, ctxErr
+		}
+	}
+	if l := logger(); allowLog(ctx, l) {
+		l.ErrorContext(ctx, "Giving up on function due to error", "attempts", attempts, "error", err)
+	}
+	return attempts, fmt.Errorf("%w after %d attempts: %w", ErrRetriesExhausted, attempts, err)
+}
+
+// sleep waits for d or until ctx is done, returning ctx.Err() in the latter case.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// exponentialDelay returns base * 2^(attempt-1), capped at maxDelay (or DefaultMaxBackoff).
+func exponentialDelay(base, maxDelay time.Duration, attempt int) time.Duration {
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxBackoff
+	}
+	if base <= 0 {
+		return 0
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		if delay >= maxDelay/2 {
+			return maxDelay
+		}
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// jitteredDelay returns a random duration in [0, exponentialDelay(base, 0, attempt)].
+func jitteredDelay(rnd *rand.Rand, base time.Duration, attempt int) time.Duration {
+	upper := exponentialDelay(base, 0, attempt)
+	if upper <= 0 {
+		return 0
+	}
+	return time.Duration(rnd.Int63n(int64(upper) + 1))
+}
+
+// errOnly drops the attempt count returned by retry.
+func errOnly(_ int, err error) error {
+	return err
+}
@@ -0,0 +1,97 @@
+package panics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+func TestRetryIfExits(t *testing.T) {
+	panics.SetLogger(nil)
+
+	tests := []struct {
+		name        string
+		shouldRetry func(error) bool
+		attempts    int
+		exhausted   bool
+	}{
+		{name: "attempts run out", shouldRetry: func(error) bool { return true }, attempts: 3, exhausted: true},
+		{name: "shouldRetry rejects", shouldRetry: func(error) bool { return false }, attempts: 1, exhausted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := panics.RetryIf(3, tt.shouldRetry, func() {
+				attempts++
+				panic("boom")
+			})
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.attempts)
+			}
+			if got := errors.Is(err, panics.ErrRetriesExhausted); got != tt.exhausted {
+				t.Errorf("errors.Is(err, ErrRetriesExhausted) = %v, want %v (err: %v)", got, tt.exhausted, err)
+			}
+			if !errors.Is(err, panics.ErrPanic) {
+				t.Errorf("errors.Is(err, ErrPanic) = false, want true (err: %v)", err)
+			}
+		})
+	}
+}
+
+func TestRetryNonPositiveAttempts(t *testing.T) {
+	panics.SetLogger(nil)
+
+	tests := []struct {
+		name string
+		run  func(fn func()) error
+	}{
+		{"Retry(0)", func(fn func()) error { return panics.Retry(0, fn) }},
+		{"RetryCount(-1)", func(fn func()) error {
+			attempts, err := panics.RetryCount(-1, fn)
+			if attempts != 1 {
+				t.Errorf("attempts = %d, want 1", attempts)
+			}
+			return err
+		}},
+		{"zero RetryPolicy", func(fn func()) error { return panics.RetryPolicy{}.Run(context.Background(), fn) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := tt.run(func() {
+				calls++
+				panic("boom")
+			})
+			if calls != 1 {
+				t.Errorf("fn ran %d times, want 1", calls)
+			}
+			if !errors.Is(err, panics.ErrRetriesExhausted) {
+				t.Errorf("err = %v, want ErrRetriesExhausted", err)
+			}
+		})
+	}
+}
+
+func TestRetryLogging(t *testing.T) {
+	var buf bytes.Buffer
+	panics.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { panics.SetLogger(nil) })
+
+	panics.Retry(3, func() { panic("boom") })
+
+	out := buf.String()
+	if got := strings.Count(out, "Retrying function due to error"); got != 2 {
+		t.Errorf("logged %d retries, want 2:\n%s", got, out)
+	}
+	if got := strings.Count(out, "Giving up on function due to error"); got != 1 {
+		t.Errorf("logged giving up %d times, want 1:\n%s", got, out)
+	}
+	if !strings.Contains(out, "attempts=3") {
+		t.Errorf("give-up log has no attempts=3:\n%s", out)
+	}
+}
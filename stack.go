@@ -0,0 +1,147 @@
+package panics
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// packagePrefix prefixes the function names of this package's frames, which are trimmed from
+// the start of captured stacks.
+const packagePrefix = "github.com/rizvn/panics."
+
+// CaptureStack returns the calling goroutine's stack as structured frames, starting skip frames
+// above the caller of CaptureStack. Leading frames of the panics package and the runtime are
+// omitted, so when called from a deferred recover the stack starts at the panicking code.
+//
+// Example usage:
+//
+//	defer func() {
+//	    if r := recover(); r != nil {
+//	        for _, frame := range panics.CaptureStack(0) {
+//	            fmt.Printf("%s %s:%d\n", frame.Function, frame.File, frame.Line)
+//	        }
+//	    }
+//	}()
+func CaptureStack(skip int) []runtime.Frame {
+	return callers(skip+1, 0)
+}
+
+// callers returns up to maxFrames frames of the calling goroutine's stack, starting skip frames
+// above the caller of callers. Leading frames of this package and the runtime are dropped so the
+// stack starts at user code. A maxFrames of zero or less returns all frames.
+func callers(skip int, maxFrames int) []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+
+	var frames []runtime.Frame
+	leading := true
+	it := runtime.CallersFrames(pcs)
+	for {
+		frame, more := it.Next()
+		if leading && (strings.HasPrefix(frame.Function, packagePrefix) || strings.HasPrefix(frame.Function, "runtime.")) {
+			if !more {
+				break
+			}
+			continue
+		}
+		leading = false
+		frames = append(frames, frame)
+		if !more || (maxFrames > 0 && len(frames) == maxFrames) {
+			break
+		}
+	}
+	return frames
+}
+
+// formatFrames renders frames in the same layout as debug.Stack, one function per entry
+// followed by its indented file and line.
+func formatFrames(frames []runtime.Frame) []byte {
+	var b strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&b, "%s()\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return []byte(b.String())
+}
+
+// FormatStackWithSource renders frames like a stack trace, with contextLines lines of source
+// before and after each frame's line, which is marked with ">". It reads the source files from
+// disk, so it is meant for local debugging; frames whose file can't be read, e.g. because it no
+// longer exists, are rendered without source.
+//
+// Example usage:
+//
+//	var pe *panics.PanicError
+//	if errors.As(err, &pe) {
+//	    fmt.Fprint(os.Stderr, panics.FormatStackWithSource(pe.Frames, 2))
+//	}
+func FormatStackWithSource(frames []runtime.Frame, contextLines int) string {
+	contextLines = max(contextLines, 0)
+	files := make(map[string][]string)
+	var b strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&b, "%s()\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		lines, ok := files[frame.File]
+		if !ok {
+			if src, err := os.ReadFile(frame.File); err == nil {
+				lines = strings.Split(string(src), "\n")
+			}
+			files[frame.File] = lines
+		}
+		if frame.Line < 1 || frame.Line > len(lines) {
+			continue
+		}
+		first, last := max(frame.Line-contextLines, 1), min(frame.Line+contextLines, len(lines))
+		for n := first; n <= last; n++ {
+			marker := " "
+			if n == frame.Line {
+				marker = ">"
+			}
+			fmt.Fprintf(&b, "\t%s %5d | %s\n", marker, n, lines[n-1])
+		}
+	}
+	return b.String()
+}
+
+// goroutineID parses the goroutine ID from the "goroutine N [status]:" header that starts a
+// stack trace from runtime.Stack or debug.Stack. It returns 0 if stack doesn't start that way.
+// The header format isn't covered by the Go 1 compatibility promise, so the result is best
+// effort.
+func goroutineID(stack []byte) uint64 {
+	rest, ok := bytes.CutPrefix(stack, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	digits, _, ok := bytes.Cut(rest, []byte(" "))
+	if !ok {
+		return 0
+	}
+	id, err := strconv.ParseUint(string(digits), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// allStacks returns the stack traces of all goroutines, starting with the calling one, in the
+// format of runtime.Stack. The buffer is grown until the whole dump fits.
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,41 @@
+package panics
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack string
+		want  uint64
+	}{
+		{"normal", "goroutine 7 [running]:\nmain.main()\n", 7},
+		{"missing prefix", "7 [running]:\n", 0},
+		{"non-numeric", "goroutine x [running]:\n", 0},
+		{"empty", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goroutineID([]byte(tt.stack)); got != tt.want {
+				t.Errorf("goroutineID(%q) = %d, want %d", tt.stack, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPanicErrorTimeAndGoroutineID(t *testing.T) {
+	var pe *PanicError
+	if !errors.As(Try(func() { panic("boom") }), &pe) {
+		t.Fatal("Try didn't return a *PanicError")
+	}
+	if pe.Time.IsZero() {
+		t.Error("Time is zero")
+	}
+	buf := make([]byte, 64)
+	if want := goroutineID(buf[:runtime.Stack(buf, false)]); pe.GoroutineID != want {
+		t.Errorf("GoroutineID = %d, want %d", pe.GoroutineID, want)
+	}
+}
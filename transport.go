@@ -0,0 +1,35 @@
+package panics
+
+import "net/http"
+
+// RecoveringTransport wraps next so that a panic inside its RoundTrip is recovered and returned
+// as the request's error instead of crashing the client's caller. The error is a *PanicError;
+// it is not logged. The request body, if any, is closed. A nil next uses http.DefaultTransport.
+//
+// Example usage:
+//
+//	client := &http.Client{Transport: panics.RecoveringTransport(signingTransport)}
+func RecoveringTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recoveringTransport{next: next}
+}
+
+// recoveringTransport is the http.RoundTripper returned by RecoveringTransport.
+type recoveringTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip calls the wrapped transport, turning a panic into a nil response and an error.
+// The request body is closed after a panic, as http.RoundTripper requires even on errors.
+func (t *recoveringTransport) RoundTrip(r *http.Request) (resp *http.Response, err error) {
+	defer RecoverAndHandle(func(err2 error) {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		resp, err = nil, err2
+	})
+
+	return t.next.RoundTrip(r)
+}
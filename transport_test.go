@@ -0,0 +1,49 @@
+package panics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRecoveringTransportClosesBody(t *testing.T) {
+	transport := panics.RecoveringTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		panic("boom")
+	}))
+	body := &closeRecorder{Reader: strings.NewReader("payload")}
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if resp != nil || !errors.Is(err, panics.ErrPanic) {
+		t.Fatalf("RoundTrip = %v, %v; want nil and a panic error", resp, err)
+	}
+	if !body.closed {
+		t.Error("request body wasn't closed")
+	}
+
+	noBody, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := transport.RoundTrip(noBody); !errors.Is(err, panics.ErrPanic) {
+		t.Errorf("RoundTrip without body = %v, want a panic error", err)
+	}
+}
@@ -0,0 +1,52 @@
+package panics_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rizvn/panics"
+)
+
+var sink int
+
+func add(a, b int) { sink = a + b }
+
+// BenchmarkTryClosure shows that a closure capturing its arguments doesn't escape through Try,
+// so guarding a call with Try doesn't allocate.
+func BenchmarkTryClosure(b *testing.B) {
+	b.ReportAllocs()
+	x, y := 1, 2
+	for b.Loop() {
+		_ = panics.Try(func() { add(x, y) })
+	}
+}
+
+func TestTryPanicValueString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil", nil, "panic: runtime error: panic called with nil argument"},
+		{"string", "boom", "panic: boom"},
+		{"error", errors.New("boom"), "panic: boom"},
+		{"int", 42, "panic: 42 (int)"},
+		{"struct", struct{}{}, "panic: {} (struct {})"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := panics.Try(func() { panic(tt.value) })
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Try: got %v, want %q", err, tt.want)
+			}
+			func() {
+				defer panics.RecoverAndHandle(func(err error) {
+					if err.Error() != tt.want {
+						t.Errorf("RecoverAndHandle: got %q, want %q", err, tt.want)
+					}
+				})
+				panic(tt.value)
+			}()
+		})
+	}
+}